@@ -40,56 +40,236 @@ type T interface {
 
 // Assert fails the test if the condition is false.
 func Assert(tb T, condition bool, msg string, v ...interface{}) {
-	if Internal.NotAssert(0, condition, msg, v...) {
+	helperOf(tb)()
+	if Internal.For(tb).NotAssert(0, condition, msg, v...) {
 		tb.FailNow()
 	}
 }
 
 // MustFail checks if err == nil. If so, it fails the test
 func MustFail(tb T, err error, msg string, v ...interface{}) {
-	if Internal.NotAssert(0, err != nil, msg, v...) {
+	helperOf(tb)()
+	if Internal.For(tb).NotAssert(0, err != nil, msg, v...) {
 		tb.FailNow()
 	}
 }
 
 // MustFailWith checks if err equals an expected error. If not, it will fail the test.
-func MustFailWith(tb T, err error, expectedError error) {
-	if Internal.NotAssert(0, err == expectedError, fmt.Sprintf("Expected error to be '%s'. Got '%s'",
+func MustFailWith(tb T, err error, expectedError error, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotAssert(0, err == expectedError, fmt.Sprintf("Expected error to be '%s'. Got '%s'",
 		Internal.ErrorString(expectedError), Internal.ErrorString(err))) {
 		tb.FailNow()
 	}
 }
 
-// Ok fails the test if an err is not nil.
-func Ok(tb T, err error) {
-	if Internal.NotOk(0, err) {
+// MustFailIs fails the test if neither err nor any error it wraps matches target,
+// as per errors.Is, printing the chain of wrapped errors
+func MustFailIs(tb T, err, target error, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotErrorIs(0, err, target) {
 		tb.FailNow()
 	}
 }
 
-// Equals fails the test if exp is not equal to act.
-func Equals(tb T, expected, actual interface{}) {
-	if Internal.NotEquals(0, expected, actual) {
+// MustFailAs fails the test if neither err nor any error it wraps can be assigned
+// to the value target points to, as per errors.As, which is otherwise set to it
+func MustFailAs(tb T, err error, target interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotErrorAs(0, err, target) {
 		tb.FailNow()
 	}
 }
 
-// JSONEquals fails if provided JSONs are not equivalent
-func JSONEquals(tb T, expected, actual []byte) {
-	if Internal.NotJSONEquals(0, expected, actual) {
+// MustFailMatching fails the test if err's message does not match the
+// regular expression expr, printing the chain of wrapped errors
+func MustFailMatching(tb T, err error, expr string, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotErrorMatches(0, err, expr) {
+		tb.FailNow()
+	}
+}
+
+// Ok fails the test if an err is not nil. Like the rest of checks, it takes an
+// optional message and arguments that are added to the failure report
+func Ok(tb T, err error, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotOk(0, err) {
+		tb.FailNow()
+	}
+}
+
+// Equals fails the test if exp is not equal to act. Options such as
+// IgnoreFields or SortSlices relax the comparison, see TestTools.Equals
+func Equals(tb T, expected, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tb)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if Internal.For(tb).WithMessage(msgAndArgs...).notEqualsWith(0, expected, actual, newOptions(options{}, opts)) {
+		tb.FailNow()
+	}
+}
+
+// JSONEquals fails if provided JSONs are not equivalent.
+// Options such as IgnorePaths or UnorderedArrays relax the comparison.
+// They go before the optional message and arguments
+func JSONEquals(tb T, expected, actual []byte, optsAndMsg ...interface{}) {
+	helperOf(tb)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotJSONEquals(0, expected, actual, opts...) {
+		tb.FailNow()
+	}
+}
+
+// JSONContains fails if the actual JSON does not contain the expected one,
+// that is, if it is not equivalent once its extra object members are ignored
+func JSONContains(tb T, expected, actual []byte, optsAndMsg ...interface{}) {
+	helperOf(tb)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotJSONEquals(0, expected, actual, append(opts, JSONSubset())...) {
 		tb.FailNow()
 	}
 }
 
 // JSONEqualsString performs a JSON comparison of the given object
 // with the JSON contained in the referenced string
-func JSONEqualsString(tb T, expected string, actual interface{}) {
+func JSONEqualsString(tb T, expected string, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tb)()
 	actualBytes, err := json.Marshal(actual)
 	if err != nil {
 		//tt.Fatalf("Cannot marshal 'actual' to JSON: %s", err)
 		tb.FailNow()
 	}
-	if Internal.NotJSONEquals(0, []byte(expected), actualBytes) {
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotJSONEquals(0, []byte(expected), actualBytes, opts...) {
+		tb.FailNow()
+	}
+}
+
+// JSONMatchesSchema fails if actual, once marshalled to JSON, does not validate
+// against the JSON Schema stored in schemaPath. See TestTools.JSONMatchesSchema
+func JSONMatchesSchema(tb T, schemaPath string, actual interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	in := Internal.For(tb).WithMessage(msgAndArgs...)
+	actualBytes, err := schemaInstance(actual)
+	if err != nil {
+		in.Fatalf(0, "Cannot marshal 'actual' to JSON: %s", err)
+		tb.FailNow()
+	}
+	if in.NotMatchesSchema(0, schemaPath, actualBytes) {
+		tb.FailNow()
+	}
+}
+
+// Contains fails the test if container does not hold element, see TestTools.Contains
+func Contains(tb T, container, element interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotContains(0, container, element) {
+		tb.FailNow()
+	}
+}
+
+// NotContains fails the test if container holds element, see TestTools.Contains
+func NotContains(tb T, container, element interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotExcludes(0, container, element) {
+		tb.FailNow()
+	}
+}
+
+// ElementsMatch fails the test if both collections don't hold the same elements
+// the same number of times, in any order, listing the missing and extra ones
+func ElementsMatch(tb T, expected, actual interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotElementsMatch(0, expected, actual) {
+		tb.FailNow()
+	}
+}
+
+// Subset fails the test if some element of subset is not in list, see TestTools.Subset
+func Subset(tb T, list, subset interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotSubset(0, list, subset) {
+		tb.FailNow()
+	}
+}
+
+// Len fails the test if object, a collection or a string, does not have the given length
+func Len(tb T, object interface{}, length int, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotLen(0, object, length) {
+		tb.FailNow()
+	}
+}
+
+// Empty fails the test if object, a collection or a string, is not nil nor empty
+func Empty(tb T, object interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotEmpty(0, object) {
+		tb.FailNow()
+	}
+}
+
+// HasKey fails the test if the map m does not have the given key
+func HasKey(tb T, m, key interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotHasKey(0, m, key) {
+		tb.FailNow()
+	}
+}
+
+// IsSorted fails the test if the elements of a slice or array of numbers
+// or strings are not in ascending order
+func IsSorted(tb T, list interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotSorted(0, list) {
+		tb.FailNow()
+	}
+}
+
+// InDelta fails the test if two numbers differ by more than delta, see TestTools.InDelta
+func InDelta(tb T, expected, actual interface{}, delta float64, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotInDelta(0, expected, actual, delta) {
+		tb.FailNow()
+	}
+}
+
+// InEpsilon fails the test if the relative error between two numbers exceeds epsilon
+func InEpsilon(tb T, expected, actual interface{}, epsilon float64, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotInEpsilon(0, expected, actual, epsilon) {
+		tb.FailNow()
+	}
+}
+
+// Greater fails the test unless a > b, see TestTools.Greater
+func Greater(tb T, a, b interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotGreater(0, a, b) {
+		tb.FailNow()
+	}
+}
+
+// Less fails the test unless a < b, see TestTools.Greater
+func Less(tb T, a, b interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotLess(0, a, b) {
+		tb.FailNow()
+	}
+}
+
+// Between fails the test unless min <= value <= max, see TestTools.Greater
+func Between(tb T, value, min, max interface{}, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotBetween(0, value, min, max) {
+		tb.FailNow()
+	}
+}
+
+// AssertThat fails the test if actual does not satisfy matcher, see TestTools.AssertThat
+func AssertThat(tb T, actual interface{}, matcher Matcher, msgAndArgs ...interface{}) {
+	helperOf(tb)()
+	if Internal.For(tb).WithMessage(msgAndArgs...).NotMatches(0, actual, matcher) {
 		tb.FailNow()
 	}
 }
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/epiclabs-io/ut"
@@ -29,6 +31,8 @@ type fakeT struct {
 	fail    bool
 	stopped bool
 	name    string
+	mu      sync.Mutex
+	output  strings.Builder
 }
 
 func (t *fakeT) Error(args ...interface{}) {
@@ -57,8 +61,21 @@ func (t *fakeT) Fatalf(format string, args ...interface{}) {
 	t.Log(fmt.Sprintln(args...))
 	t.FailNow()
 }
-func (t *fakeT) Log(args ...interface{})                 {}
-func (t *fakeT) Logf(format string, args ...interface{}) {}
+func (t *fakeT) Log(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintln(&t.output, args...)
+}
+func (t *fakeT) Logf(format string, args ...interface{}) {
+	t.Log(fmt.Sprintf(format, args...))
+}
+
+// Output returns what was logged through the test
+func (t *fakeT) Output() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.output.String()
+}
 func (t *fakeT) Name() string {
 	if t.name == "" {
 		return "testname"
@@ -0,0 +1,174 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	hexdumpRowSize     = 16
+	hexdumpRowsBefore  = 2
+	hexdumpWindowLines = 8
+)
+
+// EqualsBinaryFile checks if the passed "actual" bytes are identical
+// to the contents of the indicated file in the current test's
+// testdata folder
+func (tt *TestTools) EqualsBinaryFile(file string, actual []byte, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	tt.equalsBinaryFile(tt.in().WithMessage(msgAndArgs...), 0, file, actual)
+}
+
+// EqualsBinaryReader checks if the data read from r until EOF is identical
+// to the contents of the indicated file in the current test's
+// testdata folder
+func (tt *TestTools) EqualsBinaryReader(file string, r io.Reader, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	actual, err := ioutil.ReadAll(r)
+	if err != nil {
+		tt.Fatalf("Cannot read actual data: %s", err)
+	}
+	tt.equalsBinaryFile(tt.in().WithMessage(msgAndArgs...), 0, file, actual)
+}
+
+func (tt *TestTools) equalsBinaryFile(in *internal, callDepth int, file string, actual []byte) {
+	helperOf(tt.T)()
+	tt.touchFile(file)
+	path := filepath.Join(tt.TestdataDir, file)
+	if tt.shouldGenerate(file) {
+		CreateDirectory(filepath.Dir(path))
+		err := ioutil.WriteFile(path, actual, 0660)
+		if err != nil {
+			tt.Fatalf("Cannot write test result file %s : %s", path, err)
+		}
+		removeReceived(path)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		tt.Fatalf("Cannot open test result file %s : %s", path, err)
+	}
+	if in.NotBytesEquals(callDepth+1, expected, actual) {
+		tt.writeReceived(path, actual)
+		tt.Error(fmt.Errorf("Binary data doesn't match. Check file '%s' in testdata/%s", file, tt.T.Name()))
+	}
+	removeReceived(path)
+}
+
+// firstDifference returns the offset of the first byte that differs between a and b.
+// If one is a prefix of the other, that is the length of the shortest one
+func firstDifference(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// hexdumpRow formats a 16-byte row of data starting at offset, or an empty
+// string if data has no bytes at that offset
+func hexdumpRow(data []byte, offset int) string {
+	if offset >= len(data) {
+		return ""
+	}
+	var hex, ascii strings.Builder
+	for i := offset; i < offset+hexdumpRowSize; i++ {
+		if i == offset+hexdumpRowSize/2 {
+			hex.WriteByte(' ')
+		}
+		if i >= len(data) {
+			hex.WriteString("   ")
+			continue
+		}
+		fmt.Fprintf(&hex, "%02x ", data[i])
+		if data[i] >= 0x20 && data[i] < 0x7f {
+			ascii.WriteByte(data[i])
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+	return fmt.Sprintf("%08x  %s |%s|", offset, hex.String(), ascii.String())
+}
+
+// hexdumpMarkers returns a line with carets under the bytes that differ in a row
+func hexdumpMarkers(expected, actual []byte, offset int) string {
+	var markers strings.Builder
+	markers.WriteString(strings.Repeat(" ", 10))
+	for i := offset; i < offset+hexdumpRowSize; i++ {
+		if i == offset+hexdumpRowSize/2 {
+			markers.WriteByte(' ')
+		}
+		if i < len(expected) && i < len(actual) && expected[i] == actual[i] ||
+			i >= len(expected) && i >= len(actual) {
+			markers.WriteString("   ")
+		} else {
+			markers.WriteString("^^ ")
+		}
+	}
+	return strings.TrimRight(markers.String(), " ")
+}
+
+// hexdumpDiff renders an aligned hexdump of expected and actual around the
+// given offset. Identical rows are printed once, differing rows are printed
+// twice, prefixed with - (expected) and + (actual) and followed by carets
+// pointing at the differing bytes
+func hexdumpDiff(expected, actual []byte, offset int) string {
+	var buf bytes.Buffer
+	start := (offset/hexdumpRowSize - hexdumpRowsBefore) * hexdumpRowSize
+	if start < 0 {
+		start = 0
+	}
+	length := len(expected)
+	if len(actual) > length {
+		length = len(actual)
+	}
+	lines := 0
+	row := start
+	for ; row < length && lines < hexdumpWindowLines; row += hexdumpRowSize {
+		expectedRow := hexdumpRow(expected, row)
+		actualRow := hexdumpRow(actual, row)
+		if expectedRow == actualRow {
+			fmt.Fprintf(&buf, "\t  %s\n", expectedRow)
+			lines++
+			continue
+		}
+		if expectedRow != "" {
+			fmt.Fprintf(&buf, "\t- %s\n", expectedRow)
+		}
+		if actualRow != "" {
+			fmt.Fprintf(&buf, "\t+ %s\n", actualRow)
+		}
+		fmt.Fprintf(&buf, "\t  %s\n", hexdumpMarkers(expected, actual, row))
+		lines += 2
+	}
+	if row < length {
+		buf.WriteString("\t  ...\n")
+	}
+	return buf.String()
+}
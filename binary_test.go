@@ -0,0 +1,103 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestEqualsBinaryFile(t *testing.T) {
+	dir := tempTestdata(t)
+
+	data := ut.RandomArray(1, 100)
+	if err := ioutil.WriteFile(filepath.Join(dir, "data.bin"), data, 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	ft, early, _ := MetaTester("EqualsBinaryFile", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsBinaryFile("data.bin", data)
+		tt.EqualsBinaryReader("data.bin", bytes.NewReader(data))
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected identical binary data to pass")
+	}
+
+	modified := append([]byte{}, data...)
+	modified[40] ^= 0xff
+	for _, actual := range [][]byte{modified, data[:90], append(data, 0)} {
+		ft, early, _ = MetaTester("EqualsBinaryFile", func(tt *ut.TestTools) {
+			tt.TestdataDir = dir
+			tt.EqualsBinaryFile("data.bin", actual)
+		})
+		if !early || !ft.fail {
+			t.Fatalf("Expected different binary data to fail the test")
+		}
+	}
+}
+
+func TestBinaryOutput(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	modified := append([]byte{}, data...)
+	modified[40] ^= 0xff
+
+	var tests = []struct {
+		name     string
+		actual   []byte
+		expected []string
+	}{
+		{"modified byte", modified, []string{
+			"binary data differs at offset 0x28 (40)\n\n\t  00000000  00 01 02",
+			"\t- 00000020  20 21 22 23 24 25 26 27  28 29 2a 2b 2c 2d 2e 2f  | !\"#$%&'()*+,-./|\n",
+			"\t+ 00000020  20 21 22 23 24 25 26 27  d7 29 2a 2b 2c 2d 2e 2f  | !\"#$%&'.)*+,-./|\n",
+			"\t  " + strings.Repeat(" ", 35) + "^^\n",
+			"\t  00000060  60 61",
+			"\t  ...\n",
+		}},
+		{"shorter", data[:250], []string{
+			"binary data differs at offset 0xfa (250)\n\tlength mismatch: expected 256 bytes, got 250\n\n\t  000000d0  d0 d1",
+			"\t- 000000f0  f0 f1 f2 f3 f4 f5 f6 f7  f8 f9 fa fb fc fd fe ff  |................|\n",
+			"\t+ 000000f0  f0 f1 f2 f3 f4 f5 f6 f7  f8 f9                    |..........|\n",
+			"\t  " + strings.Repeat(" ", 41) + "^^ ^^ ^^ ^^ ^^ ^^\n",
+		}},
+		{"longer", append(data, 0), []string{
+			"binary data differs at offset 0x100 (256)\n\tlength mismatch: expected 256 bytes, got 257\n\n\t  000000e0  e0 e1",
+			"\t+ 00000100  00 ",
+			"|.|\n\t            ^^\n",
+		}},
+	}
+
+	for _, test := range tests {
+		ft := new(fakeT)
+		ut.Internal.For(ft).NotBytesEquals(0, data, test.actual)
+		output := ft.Output()
+		for _, expected := range test.expected {
+			if !strings.Contains(output, expected) {
+				t.Fatalf("%s: expected the failure to contain %q, got:\n%s", test.name, expected, output)
+			}
+		}
+	}
+}
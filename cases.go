@@ -0,0 +1,101 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GoldenCaseFunc runs the code under test on the input of a golden case
+// and returns the output to compare with the case's golden file
+type GoldenCaseFunc func(tt *TestTools, input []byte) interface{}
+
+// RunGoldenCases runs fn once for every NAME.in file in the given subfolder of
+// the current test's testdata folder, comparing its output with NAME.out.
+// Strings are compared as text, byte slices as binary data and any other
+// value with EqualsFile. Each case runs as a subtest if the underlying T
+// supports it (as *testing.T does), or else as a StartSubTest section.
+// When generating results, .out files are created for new inputs
+func (tt *TestTools) RunGoldenCases(dir string, fn GoldenCaseFunc) {
+	helperOf(tt.T)()
+	inputs, err := filepath.Glob(filepath.Join(tt.TestdataDir, dir, "*.in"))
+	if err != nil {
+		tt.Fatalf("Cannot list golden cases in %s : %s", dir, err)
+	}
+	if len(inputs) == 0 {
+		tt.Fatalf("Cannot find golden cases (*.in files) in testdata/%s/%s", tt.T.Name(), dir)
+	}
+
+	runner, hasRun := tt.T.(interface {
+		Run(name string, f func(t *testing.T)) bool
+	})
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".in")
+		if hasRun {
+			runner.Run(name, func(t *testing.T) {
+				sub := tt.subTools(t)
+				defer sub.FinishTest()
+				sub.runGoldenCase(dir, name, fn)
+			})
+			continue
+		}
+		tt.StartSubTest("%s", name)
+		tt.runGoldenCase(dir, name, fn)
+		tt.EndSubTest()
+	}
+}
+
+func (tt *TestTools) runGoldenCase(dir, name string, fn GoldenCaseFunc) {
+	helperOf(tt.T)()
+	inputFile := filepath.Join(dir, name+".in")
+	outputFile := filepath.Join(dir, name+".out")
+	tt.touchFile(inputFile)
+	input, err := ioutil.ReadFile(filepath.Join(tt.TestdataDir, inputFile))
+	if err != nil {
+		tt.Fatalf("Cannot read golden case input %s : %s", inputFile, err)
+	}
+
+	switch output := fn(tt, input).(type) {
+	case string:
+		tt.EqualsTextFile(outputFile, output)
+	case []byte:
+		tt.EqualsBinaryFile(outputFile, output)
+	default:
+		tt.EqualsFile(outputFile, output)
+	}
+}
+
+// subTools returns the TestTools for a subtest, which shares
+// the testdata folder and settings of its parent
+func (tt *TestTools) subTools(t T) *TestTools {
+	return &TestTools{
+		err:               make(chan error, 20),
+		T:                 t,
+		TestdataDir:       tt.TestdataDir,
+		SharedTestdataDir: tt.SharedTestdataDir,
+		Codec:             tt.Codec,
+		generateResults:   tt.generateResults,
+		update:            tt.update,
+		Results:           tt.Results,
+		scrubbers:         tt.scrubbers,
+		comparers:         tt.comparers,
+		parent:            tt,
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func describe(tt *ut.TestTools, input []byte) interface{} {
+	switch {
+	case bytes.HasPrefix(input, []byte("{")):
+		var doc map[string]interface{}
+		tt.Ok(json.Unmarshal(input, &doc))
+		return struct {
+			Keys int `json:"keys"`
+		}{len(doc)}
+	case bytes.IndexByte(input, 0) >= 0:
+		return bytes.ToUpper(input)
+	default:
+		return strings.ToUpper(string(input))
+	}
+}
+
+func TestGoldenCases(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.RunGoldenCases("cases", describe)
+}
+
+func TestGoldenCasesWithoutRun(t *testing.T) {
+	ft, early, _ := MetaTester("GoldenCasesWithoutRun", func(tt *ut.TestTools) {
+		tt.TestdataDir = "testdata/TestGoldenCases"
+		tt.RunGoldenCases("cases", describe)
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected golden cases to pass")
+	}
+
+	dir := tempTestdata(t)
+	copyTestdata(t, "testdata/TestGoldenCases/cases", filepath.Join(dir, "cases"))
+
+	ft, early, _ = MetaTester("GoldenCasesWithoutRun", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.RunGoldenCases("cases", func(tt *ut.TestTools, input []byte) interface{} {
+			return string(input)
+		})
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected golden cases with different output to fail")
+	}
+}
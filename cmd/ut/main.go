@@ -0,0 +1,81 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+// Command ut manages the golden data that µt tests keep in their testdata folders.
+//
+// Usage:
+//
+//	ut prune [-delete] [packages]
+//	ut received [-diff] [files]
+//	ut review [files]
+//	ut approve [files]
+//	ut reject [files]
+//
+// prune runs the tests of the given packages (./... by default), recording
+// which golden files and results.json keys every passing test uses, and then
+// lists the golden data those tests no longer use. Since files not read
+// through µt, such as fixtures opened directly, are listed too, nothing is
+// deleted unless -delete is given.
+//
+// When a golden comparison fails, the actual value is written next to the
+// golden file with a .received suffix. received lists these files under the
+// current folder, review shows their differences and asks whether to approve
+// or reject each of them, and approve and reject do so without asking.
+// Approving moves the file into place, or merges its keys into results.json.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"prune", "prune [-delete] [packages]: list (or delete) golden data not used by any passing test", prune},
+	{"received", "received [-diff] [files]: list the received files pending review", received},
+	{"review", "review [files]: show each received file and ask whether to approve it", review},
+	{"approve", "approve [files]: make received files the new golden files", approve},
+	{"reject", "reject [files]: discard received files", reject},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: ut <command> [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\t%s\n", c.usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "ut %s: %s\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+}
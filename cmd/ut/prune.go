@@ -0,0 +1,177 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func prune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	del := fs.Bool("delete", false, "delete the unused golden data instead of only printing it")
+	fs.Parse(args)
+	packages := fs.Args()
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	recordDir, err := ioutil.TempDir("", "ut-prune")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(recordDir)
+
+	cmd := exec.Command("go", append([]string{"test", "-count=1"}, packages...)...)
+	cmd.Env = append(os.Environ(), ut.RecordEnv+"="+recordDir, ut.OrphansEnv+"="+ut.OrphansOff)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Tests failed (%s), only golden data of passing tests will be pruned\n", err)
+	}
+
+	records, err := loadRecords(recordDir)
+	if err != nil {
+		return err
+	}
+
+	dirs := make([]string, 0, len(records))
+	for dir := range records {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	verb := "would delete"
+	if *del {
+		verb = "delete"
+	}
+	found := false
+	for _, dir := range dirs {
+		r := records[dir]
+		files, keys, err := ut.FindOrphans(dir, r.Files, r.Keys)
+		if err != nil {
+			return fmt.Errorf("cannot inspect %s: %s", dir, err)
+		}
+		for _, file := range files {
+			path := filepath.Join(dir, filepath.FromSlash(file))
+			fmt.Printf("%s %s\n", verb, path)
+			found = true
+			if *del {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+		}
+		if len(keys) > 0 {
+			found = true
+			for _, key := range keys {
+				fmt.Printf("%s key '%s' in %s\n", verb, key, filepath.Join(dir, "results.json"))
+			}
+			if *del {
+				if err := deleteKeys(dir, keys); err != nil {
+					return err
+				}
+			}
+		}
+		if *del {
+			removeEmptyDirs(dir)
+		}
+	}
+	if found && !*del {
+		fmt.Println("Run with -delete to delete them")
+	}
+	return nil
+}
+
+// loadRecords reads all the records written by the tests and merges
+// them by testdata folder, since several tests may share one
+func loadRecords(recordDir string) (map[string]*ut.Record, error) {
+	entries, err := ioutil.ReadDir(recordDir)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]*ut.Record)
+	for _, entry := range entries {
+		recordBytes, err := ioutil.ReadFile(filepath.Join(recordDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var r ut.Record
+		if err := json.Unmarshal(recordBytes, &r); err != nil {
+			return nil, fmt.Errorf("cannot decode record %s: %s", entry.Name(), err)
+		}
+		merged, ok := records[r.TestdataDir]
+		if !ok {
+			records[r.TestdataDir] = &r
+			continue
+		}
+		merged.Files = append(merged.Files, r.Files...)
+		merged.Keys = append(merged.Keys, r.Keys...)
+	}
+	return records, nil
+}
+
+// deleteKeys removes the given keys from the results.json file in dir,
+// removing the file altogether if it ends up empty
+func deleteKeys(dir string, keys []string) error {
+	path := filepath.Join(dir, "results.json")
+	resultBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var results map[string]json.RawMessage
+	if err := json.Unmarshal(resultBytes, &results); err != nil {
+		return fmt.Errorf("cannot decode %s: %s", path, err)
+	}
+	for _, key := range keys {
+		delete(results, key)
+	}
+	if len(results) == 0 {
+		return os.Remove(path)
+	}
+	resultBytes, err = json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, resultBytes, 0660)
+}
+
+// removeEmptyDirs deletes dir and its subfolders if they are left empty
+func removeEmptyDirs(dir string) bool {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	empty := true
+	for _, entry := range entries {
+		if !entry.IsDir() || !removeEmptyDirs(filepath.Join(dir, entry.Name())) {
+			empty = false
+		}
+	}
+	if empty {
+		return os.Remove(dir) == nil
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/epiclabs-io/ut"
+)
+
+// pendingFiles returns the received files given as arguments,
+// or all the received files under the current folder if there are none
+func pendingFiles(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	return ut.FindReceived(".")
+}
+
+func received(args []string) error {
+	fs := flag.NewFlagSet("received", flag.ExitOnError)
+	showDiff := fs.Bool("diff", false, "show the differences with the golden files")
+	fs.Parse(args)
+
+	files, err := pendingFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		fmt.Println(file)
+		if *showDiff {
+			diff, err := ut.DiffReceived(file)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", diff)
+		}
+	}
+	return nil
+}
+
+func approve(args []string) error {
+	files, err := pendingFiles(args)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := ut.ApproveReceived(file); err != nil {
+			return err
+		}
+		fmt.Printf("approved %s\n", file)
+	}
+	return nil
+}
+
+func reject(args []string) error {
+	files, err := pendingFiles(args)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := ut.RejectReceived(file); err != nil {
+			return err
+		}
+		fmt.Printf("rejected %s\n", file)
+	}
+	return nil
+}
+
+func review(args []string) error {
+	files, err := pendingFiles(args)
+	if err != nil {
+		return err
+	}
+	in := bufio.NewReader(os.Stdin)
+	for _, file := range files {
+		diff, err := ut.DiffReceived(file)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s:\n%s\n", file, diff)
+	ask:
+		fmt.Print("[a]pprove, [r]eject, [s]kip, [q]uit? ")
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch strings.TrimSpace(answer) {
+		case "a":
+			err = ut.ApproveReceived(file)
+		case "r":
+			err = ut.RejectReceived(file)
+		case "s":
+		case "q":
+			return nil
+		default:
+			goto ask
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
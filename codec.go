@@ -0,0 +1,114 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+)
+
+// Codec converts values to and from the format golden results are stored in
+type Codec interface {
+	// Marshal encodes v
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v. Codecs that cannot decode return
+	// ErrUnmarshalNotSupported, and their golden results are then compared
+	// in encoded form
+	Unmarshal(data []byte, v interface{}) error
+	// Extension is appended to golden file names that have none, e.g. ".txt".
+	// File names are kept as they are when the default JSON codec is used
+	Extension() string
+	// Diff renders the differences between two encoded values
+	Diff(expected, actual []byte) string
+}
+
+// ErrUnmarshalNotSupported is returned by codecs that can only encode values
+var ErrUnmarshalNotSupported = errors.New("codec does not support unmarshalling")
+
+var (
+	// JSONCodec stores golden results as indented JSON. It is the default codec
+	JSONCodec Codec = jsonCodec{}
+
+	// GoCodec stores golden results as deterministic Go-like syntax. It works
+	// for any value, including maps with struct keys, unexported fields and
+	// interfaces, but results are compared in their printed form
+	GoCodec Codec = goCodec{}
+)
+
+// WithCodec makes EqualsFile or EqualsKey use the given codec
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "\t")
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Extension() string {
+	return ".json"
+}
+
+func (jsonCodec) Diff(expected, actual []byte) string {
+	return textDiff(string(Internal.JSONPretty(expected)), string(Internal.JSONPretty(actual)))
+}
+
+type goCodec struct{}
+
+func (goCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(formatGo(v) + "\n"), nil
+}
+
+func (goCodec) Unmarshal(data []byte, v interface{}) error {
+	return ErrUnmarshalNotSupported
+}
+
+func (goCodec) Extension() string {
+	return ".txt"
+}
+
+func (goCodec) Diff(expected, actual []byte) string {
+	return textDiff(string(expected), string(actual))
+}
+
+// goldenFileName appends the codec's extension to file names without one,
+// unless the codec is JSONCodec, so existing golden files keep their names
+func goldenFileName(file string, codec Codec) string {
+	if _, isJSON := codec.(jsonCodec); !isJSON && filepath.Ext(file) == "" {
+		return file + codec.Extension()
+	}
+	return file
+}
+
+// NotEncodedEquals compares two values encoded with the given codec byte by byte
+func (in *internal) NotEncodedEquals(callDepth int, codec Codec, expected, actual []byte) bool {
+	helperOf(in.t)()
+	if !bytes.Equal(expected, actual) {
+		in.failf(callDepth, "\n\n%s", codec.Diff(expected, actual))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type point struct {
+	X, Y int
+}
+
+type node struct {
+	Name     string
+	Next     *node
+	Labels   map[point]string
+	Value    interface{}
+	created  time.Time
+	internal []byte
+}
+
+func TestGoCodec(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	n := &node{
+		Name: "root",
+		Labels: map[point]string{
+			{2, 1}: "b",
+			{1, 2}: "a",
+		},
+		Value:    []interface{}{1.5, "two", nil},
+		created:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		internal: []byte("bytes"),
+	}
+	n.Next = &node{Name: "child", Next: n}
+
+	t.EqualsFile("node", n, ut.WithCodec(ut.GoCodec))
+	t.EqualsKey("node", n, ut.WithCodec(ut.GoCodec))
+
+	t.Codec = ut.GoCodec
+	t.EqualsKey("labels", n.Labels)
+}
+
+// tempTestdata returns a temporary directory to use as testdata, which is
+// removed when the test finishes
+func tempTestdata(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ut-testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// copyTestdata copies the files of a testdata directory into dst so tests
+// that fail on purpose don't leave .received files in the source tree.
+func copyTestdata(t *testing.T, src, dst string) {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(src, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dst, f.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGoCodecMismatch(t *testing.T) {
+	dir := tempTestdata(t)
+	copyTestdata(t, "testdata/TestGoCodec", dir)
+
+	ft, early, _ := MetaTester("GoCodecMismatch", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.LoadResults()
+		tt.EqualsKey("labels", map[point]string{{1, 2}: "a"}, ut.WithCodec(ut.GoCodec))
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected different values to fail the test")
+	}
+}
+
+func TestLegacyGoldenFileName(t *testing.T) {
+	dir := tempTestdata(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "golden"), []byte(`{"X":1,"Y":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ft, early, _ := MetaTester("LegacyGoldenFileName", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsFile("golden", point{1, 2})
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected extensionless golden file to be used with the JSON codec:\n%s", ft.Output())
+	}
+}
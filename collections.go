@@ -0,0 +1,426 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxCollectionElements is how many missing or extra elements are listed
+// unless verbose output is requested
+const maxCollectionElements = 20
+
+// Contains checks that container holds element: a substring for strings, a value
+// for maps, or an element for slices, arrays and channels. Channels are checked
+// by receiving the elements they have buffered, which are then sent back in the
+// same order, so they must not be used by other goroutines meanwhile.
+// Receive-only and send-only channels cannot be checked this way
+func (tt *TestTools) Contains(container, element interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotContains(0, container, element) {
+		tt.Error(errors.New("Element not found"))
+	}
+}
+
+// NotContains checks that container does not hold element, see Contains
+func (tt *TestTools) NotContains(container, element interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotExcludes(0, container, element) {
+		tt.Error(errors.New("Unexpected element found"))
+	}
+}
+
+// ElementsMatch checks that both collections hold the same elements the same
+// number of times, in any order. Otherwise it lists the missing and extra ones
+func (tt *TestTools) ElementsMatch(expected, actual interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotElementsMatch(0, expected, actual) {
+		tt.Error(errors.New("Elements don't match"))
+	}
+}
+
+// Subset checks that every element of subset is in list or, for maps,
+// that every key of subset is in list with the same value
+func (tt *TestTools) Subset(list, subset interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotSubset(0, list, subset) {
+		tt.Error(errors.New("Not a subset"))
+	}
+}
+
+// Len checks that object, a collection or a string, has the given length
+func (tt *TestTools) Len(object interface{}, length int, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotLen(0, object, length) {
+		tt.Error(errors.New("Unexpected length"))
+	}
+}
+
+// Empty checks that object, a collection or a string, is nil or has no elements
+func (tt *TestTools) Empty(object interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotEmpty(0, object) {
+		tt.Error(errors.New("Not empty"))
+	}
+}
+
+// HasKey checks that the map m has the given key
+func (tt *TestTools) HasKey(m, key interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotHasKey(0, m, key) {
+		tt.Error(errors.New("Key not found"))
+	}
+}
+
+// IsSorted checks that the elements of a slice or array of numbers
+// or strings are in ascending order
+func (tt *TestTools) IsSorted(list interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotSorted(0, list) {
+		tt.Error(errors.New("Not sorted"))
+	}
+}
+
+// NotContains checks whether container holds element, see TestTools.Contains
+func (in *internal) NotContains(callDepth int, container, element interface{}) bool {
+	helperOf(in.t)()
+	found, err := contains(container, element)
+	if err != nil {
+		in.failf(callDepth, "Contains: %s", err)
+		return true
+	}
+	if !found {
+		in.failf(callDepth, "\n\n\t%s\n\n\tdoes not contain: %s", formatGoValue(reflect.ValueOf(container)), formatGoValue(reflect.ValueOf(element)))
+		return true
+	}
+	return false
+}
+
+// NotExcludes checks whether container does not hold element, see TestTools.NotContains
+func (in *internal) NotExcludes(callDepth int, container, element interface{}) bool {
+	helperOf(in.t)()
+	found, err := contains(container, element)
+	if err != nil {
+		in.failf(callDepth, "NotContains: %s", err)
+		return true
+	}
+	if found {
+		in.failf(callDepth, "\n\n\t%s\n\n\tshould not contain: %s", formatGoValue(reflect.ValueOf(container)), formatGoValue(reflect.ValueOf(element)))
+		return true
+	}
+	return false
+}
+
+// NotElementsMatch compares two collections as multisets
+func (in *internal) NotElementsMatch(callDepth int, expected, actual interface{}) bool {
+	helperOf(in.t)()
+	expectedElements, err := elements(expected)
+	if err != nil {
+		in.failf(callDepth, "ElementsMatch: 'expected' %s", err)
+		return true
+	}
+	actualElements, err := elements(actual)
+	if err != nil {
+		in.failf(callDepth, "ElementsMatch: 'actual' %s", err)
+		return true
+	}
+	missing, extra := multisetDifference(expectedElements, actualElements)
+	if len(missing) == 0 && len(extra) == 0 {
+		return false
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\n\telements don't match (ignoring order):\n")
+	writeElements(&b, "missing", missing)
+	writeElements(&b, "extra", extra)
+	if verbose() {
+		fmt.Fprintf(&b, "\n\texpected: %#v\n\n\tgot: %#v\n", expected, actual)
+	}
+	in.failf(callDepth, "%s", b.String())
+	return true
+}
+
+// NotSubset checks whether all the elements of subset are in list,
+// or all of its entries for maps
+func (in *internal) NotSubset(callDepth int, list, subset interface{}) bool {
+	helperOf(in.t)()
+	listValue, subsetValue := addressable(reflect.ValueOf(list)), addressable(reflect.ValueOf(subset))
+	if listValue.Kind() == reflect.Map && subsetValue.Kind() == reflect.Map {
+		var differences []goDifference
+		for _, key := range sortedMapKeyValues(subsetValue) {
+			path := "[" + formatGoValue(key) + "]"
+			value := listValue.MapIndex(key)
+			if !value.IsValid() {
+				differences = append(differences, goDifference{path: path, expected: formatGoValue(subsetValue.MapIndex(key)), missing: true})
+			} else if !reflect.DeepEqual(readable(value), readable(subsetValue.MapIndex(key))) {
+				differences = append(differences, goDifference{path: path, expected: formatGoValue(subsetValue.MapIndex(key)), actual: formatGoValue(value)})
+			}
+		}
+		if len(differences) > 0 {
+			in.failf(callDepth, "\n\n\tnot a subset of the map, %s", strings.TrimPrefix(formatGoDifferences(differences, verbose()), "\t"))
+			return true
+		}
+		return false
+	}
+
+	listElements, err := elements(list)
+	if err != nil {
+		in.failf(callDepth, "Subset: 'list' %s", err)
+		return true
+	}
+	subsetElements, err := elements(subset)
+	if err != nil {
+		in.failf(callDepth, "Subset: 'subset' %s", err)
+		return true
+	}
+	var missing []reflect.Value
+	for _, element := range subsetElements {
+		if indexOf(listElements, element) < 0 {
+			missing = append(missing, element)
+		}
+	}
+	if len(missing) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "\n\n\t%s\n\n\tis missing elements of the subset:\n", formatGoValue(listValue))
+		writeElements(&b, "missing", missing)
+		in.failf(callDepth, "%s", b.String())
+		return true
+	}
+	return false
+}
+
+// NotLen checks whether object has the given length
+func (in *internal) NotLen(callDepth int, object interface{}, length int) bool {
+	helperOf(in.t)()
+	v, err := collection(object)
+	if err != nil {
+		in.failf(callDepth, "Len: %s", err)
+		return true
+	}
+	if v.Len() != length {
+		in.failf(callDepth, "\n\n\texpected length: %d\n\n\tgot length %d: %s", length, v.Len(), formatGoValue(v))
+		return true
+	}
+	return false
+}
+
+// NotEmpty checks whether object is nil or has no elements
+func (in *internal) NotEmpty(callDepth int, object interface{}) bool {
+	helperOf(in.t)()
+	if object == nil {
+		return false
+	}
+	v, err := collection(object)
+	if err != nil {
+		in.failf(callDepth, "Empty: %s", err)
+		return true
+	}
+	if v.Len() != 0 {
+		in.failf(callDepth, "\n\n\texpected empty, got length %d: %s", v.Len(), formatGoValue(v))
+		return true
+	}
+	return false
+}
+
+// NotHasKey checks whether the map m has the given key
+func (in *internal) NotHasKey(callDepth int, m, key interface{}) bool {
+	helperOf(in.t)()
+	v := addressable(reflect.ValueOf(m))
+	if v.Kind() != reflect.Map {
+		in.failf(callDepth, "HasKey: expected a map, got %T", m)
+		return true
+	}
+	k := reflect.ValueOf(key)
+	if !k.IsValid() || !k.Type().AssignableTo(v.Type().Key()) {
+		in.failf(callDepth, "HasKey: key %#v is not a %s", key, v.Type().Key())
+		return true
+	}
+	if !v.MapIndex(k.Convert(v.Type().Key())).IsValid() {
+		keys := sortedMapKeyValues(v)
+		var b strings.Builder
+		fmt.Fprintf(&b, "\n\n\tkey not found: %s\n\n\tkeys (%d):", formatGoValue(k), len(keys))
+		for i, key := range keys {
+			if i == maxCollectionElements && !verbose() {
+				fmt.Fprintf(&b, " ... and %d more", len(keys)-i)
+				break
+			}
+			fmt.Fprintf(&b, " %s", formatGoValue(key))
+		}
+		in.failf(callDepth, "%s", b.String())
+		return true
+	}
+	return false
+}
+
+// NotSorted checks whether the elements of list are in ascending order
+func (in *internal) NotSorted(callDepth int, list interface{}) bool {
+	helperOf(in.t)()
+	v := addressable(reflect.ValueOf(list))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		in.failf(callDepth, "IsSorted: expected a slice or array, got %T", list)
+		return true
+	}
+	for i := 1; i < v.Len(); i++ {
+		less, ok := lessValue(v.Index(i), v.Index(i-1))
+		if !ok {
+			in.failf(callDepth, "IsSorted: cannot order elements of type %s", v.Type().Elem())
+			return true
+		}
+		if less {
+			in.failf(callDepth, "\n\n\tnot sorted, [%d]: %s > [%d]: %s\n\n\tgot: %s", i-1, formatGoValue(v.Index(i-1)), i, formatGoValue(v.Index(i)), formatGoValue(v))
+			return true
+		}
+	}
+	return false
+}
+
+// collection returns the value of object, which must have a length
+func collection(object interface{}) (reflect.Value, error) {
+	v := addressable(reflect.ValueOf(object))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
+		return v, nil
+	}
+	return v, fmt.Errorf("expected a slice, array, map, string or channel, got %T", object)
+}
+
+// elements returns the elements of a collection: the runes of strings,
+// the values of maps, sorted by key, or the elements buffered in channels,
+// which are left in them
+func elements(object interface{}) ([]reflect.Value, error) {
+	v, err := collection(object)
+	if err != nil {
+		return nil, err
+	}
+	var values []reflect.Value
+	switch v.Kind() {
+	case reflect.String:
+		for _, r := range v.String() {
+			values = append(values, reflect.ValueOf(r))
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeyValues(v) {
+			values = append(values, v.MapIndex(key))
+		}
+	case reflect.Chan:
+		if v.Type().ChanDir() != reflect.BothDir {
+			return nil, fmt.Errorf("cannot read the elements of %s without consuming them", v.Type())
+		}
+		for n := v.Len(); n > 0; n-- {
+			value, ok := v.TryRecv()
+			if !ok {
+				break
+			}
+			values = append(values, value)
+		}
+		for _, value := range values {
+			if !v.TrySend(value) {
+				return nil, fmt.Errorf("cannot put back the elements of %s, as it was used meanwhile", v.Type())
+			}
+		}
+	default:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i))
+		}
+	}
+	return values, nil
+}
+
+// contains tells whether container holds element, see TestTools.Contains
+func contains(container, element interface{}) (bool, error) {
+	if s := reflect.ValueOf(container); s.Kind() == reflect.String {
+		substring := reflect.ValueOf(element)
+		if substring.Kind() != reflect.String {
+			return false, fmt.Errorf("a string can only contain strings, got %T", element)
+		}
+		return strings.Contains(s.String(), substring.String()), nil
+	}
+	values, err := elements(container)
+	if err != nil {
+		return false, err
+	}
+	return indexOf(values, addressable(reflect.ValueOf(element))) >= 0, nil
+}
+
+// indexOf returns the position of the first of values deeply equal to v, or -1
+func indexOf(values []reflect.Value, v reflect.Value) int {
+	for i, value := range values {
+		if equalValues(value, v) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalValues(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	return reflect.DeepEqual(readable(a), readable(b))
+}
+
+// multisetDifference returns the elements of expected that are not in actual
+// and those of actual not in expected, counting repeated elements
+func multisetDifference(expected, actual []reflect.Value) (missing, extra []reflect.Value) {
+	matched := make([]bool, len(actual))
+	for _, e := range expected {
+		found := false
+		for i, a := range actual {
+			if !matched[i] && equalValues(e, a) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+	for i, a := range actual {
+		if !matched[i] {
+			extra = append(extra, a)
+		}
+	}
+	return missing, extra
+}
+
+// writeElements lists elements one per line, capping their number unless verbose
+func writeElements(b *strings.Builder, label string, values []reflect.Value) {
+	for i, value := range values {
+		if i == maxCollectionElements && !verbose() {
+			fmt.Fprintf(b, "\t... and %d more %s. Set UT_VERBOSE to see them all\n", len(values)-i, label)
+			break
+		}
+		fmt.Fprintf(b, "\t%s: %s\n", label, formatGoValue(value))
+	}
+}
+
+// lessValue tells whether a < b for numbers and strings; ok is false for other kinds
+func lessValue(a, b reflect.Value) (less, ok bool) {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float(), true
+	case reflect.String:
+		return a.String() < b.String(), true
+	}
+	return false, false
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type myString string
+
+func TestCollections(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+
+	var tests = []struct {
+		name  string
+		check func(tb ut.T)
+		fail  bool
+	}{
+		{"contains slice", func(tb ut.T) { ut.Contains(tb, []string{"a", "b"}, "b") }, false},
+		{"contains array", func(tb ut.T) { ut.Contains(tb, [2]point{{1, 2}, {3, 4}}, point{3, 4}) }, false},
+		{"contains map value", func(tb ut.T) { ut.Contains(tb, map[string]int{"a": 1}, 1) }, false},
+		{"contains substring", func(tb ut.T) { ut.Contains(tb, "hello world", "o w") }, false},
+		{"contains named string", func(tb ut.T) { ut.Contains(tb, myString("hello"), "ell") }, false},
+		{"contains named substring", func(tb ut.T) { ut.Contains(tb, "hello", myString("ell")) }, false},
+		{"contains channel", func(tb ut.T) { ut.Contains(tb, ch, 2) }, false},
+		{"contains receive-only channel", func(tb ut.T) { ut.Contains(tb, (<-chan int)(ch), 2) }, true},
+		{"elements match channel", func(tb ut.T) { ut.ElementsMatch(tb, []int{2, 1}, ch) }, false},
+		{"subset channel", func(tb ut.T) { ut.Subset(tb, ch, []int{1}) }, false},
+		{"contains missing", func(tb ut.T) { ut.Contains(tb, []int{1, 2}, 3) }, true},
+		{"contains other type", func(tb ut.T) { ut.Contains(tb, []int{1, 2}, int64(1)) }, true},
+		{"contains not a collection", func(tb ut.T) { ut.Contains(tb, 12, 1) }, true},
+		{"not contains", func(tb ut.T) { ut.NotContains(tb, []int{1, 2}, 3) }, false},
+		{"not contains present", func(tb ut.T) { ut.NotContains(tb, "abc", "b") }, true},
+		{"elements match", func(tb ut.T) { ut.ElementsMatch(tb, []int{1, 2, 2, 3}, []int{2, 3, 1, 2}) }, false},
+		{"elements match array and slice", func(tb ut.T) { ut.ElementsMatch(tb, [2]string{"a", "b"}, []string{"b", "a"}) }, false},
+		{"elements match repeated", func(tb ut.T) { ut.ElementsMatch(tb, []int{1, 2, 2}, []int{1, 1, 2}) }, true},
+		{"subset", func(tb ut.T) { ut.Subset(tb, []int{1, 2, 3}, []int{3, 1}) }, false},
+		{"subset missing", func(tb ut.T) { ut.Subset(tb, []int{1, 2, 3}, []int{4}) }, true},
+		{"subset map", func(tb ut.T) { ut.Subset(tb, map[string]int{"a": 1, "b": 2}, map[string]int{"b": 2}) }, false},
+		{"subset map value", func(tb ut.T) { ut.Subset(tb, map[string]int{"a": 1, "b": 2}, map[string]int{"b": 3}) }, true},
+		{"len", func(tb ut.T) { ut.Len(tb, map[int]bool{1: true, 2: false}, 2) }, false},
+		{"len string", func(tb ut.T) { ut.Len(tb, "abc", 2) }, true},
+		{"empty", func(tb ut.T) { ut.Empty(tb, []int{}) }, false},
+		{"empty nil", func(tb ut.T) { ut.Empty(tb, nil) }, false},
+		{"empty nil map", func(tb ut.T) { ut.Empty(tb, map[string]int(nil)) }, false},
+		{"not empty", func(tb ut.T) { ut.Empty(tb, "a") }, true},
+		{"has key", func(tb ut.T) { ut.HasKey(tb, map[point]string{{1, 2}: "a"}, point{1, 2}) }, false},
+		{"has key missing", func(tb ut.T) { ut.HasKey(tb, map[string]int{"a": 1}, "b") }, true},
+		{"has key not a map", func(tb ut.T) { ut.HasKey(tb, []int{1}, 0) }, true},
+		{"sorted", func(tb ut.T) { ut.IsSorted(tb, []int{1, 2, 2, 5}) }, false},
+		{"sorted strings", func(tb ut.T) { ut.IsSorted(tb, []string{"a", "c", "b"}) }, true},
+		{"sorted structs", func(tb ut.T) { ut.IsSorted(tb, []point{{1, 2}, {0, 1}}) }, true},
+	}
+
+	for _, test := range tests {
+		ft := new(fakeT)
+		test.check(ft)
+		if ft.fail != test.fail {
+			t.Fatalf("%s: expected failure to be %v, got %v:\n%s", test.name, test.fail, ft.fail, ft.Output())
+		}
+	}
+	if len(ch) != 2 || <-ch != 1 || <-ch != 2 {
+		t.Fatalf("Expected the checks to leave the channel elements in place")
+	}
+}
+
+func TestCollectionsOutput(t *testing.T) {
+	ft, early, _ := MetaTester("CollectionsOutput", func(tt *ut.TestTools) {
+		tt.ElementsMatch([]string{"a", "b", "b", "c"}, []string{"c", "b", "d"}, "letters")
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected different elements to fail the test")
+	}
+	expected := "letters\n\n\telements don't match (ignoring order):\n" +
+		"\tmissing: \"a\"\n\tmissing: \"b\"\n\textra: \"d\""
+	if output := ft.Output(); !strings.Contains(output, expected) {
+		t.Fatalf("Expected the missing and extra elements to be listed, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.HasKey(ft, map[string]int{"b": 2, "a": 1}, "c")
+	if output := ft.Output(); !strings.Contains(output, "key not found: \"c\"\n\n\tkeys (2): \"a\" \"b\"") {
+		t.Fatalf("Expected the keys to be listed, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.IsSorted(ft, []int{1, 3, 2})
+	if output := ft.Output(); !strings.Contains(output, "not sorted, [1]: 3 > [2]: 2") {
+		t.Fatalf("Expected the first unsorted element to be reported, got:\n%s", output)
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ColorEnv is the environment variable that sets whether diffs are colored:
+// auto (default, only if output is a terminal), always or never.
+// Setting NO_COLOR disables colors unless they are forced with always
+const ColorEnv = "UT_COLOR"
+
+// ContextEnv is the environment variable that sets the number of unchanged
+// lines shown around each change in diffs, 3 by default
+const ContextEnv = "UT_CONTEXT"
+
+// IntralineEnv is the environment variable that, when set to false, stops
+// highlighting the words that changed within long lines in diffs
+const IntralineEnv = "UT_INTRALINE"
+
+// Color modes
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// maxDiffEdits bounds the work spent looking for a minimal diff. Beyond it,
+// the remaining lines are reported as entirely replaced
+const maxDiffEdits = 1000
+
+// intralineMinLength is the length from which changed lines are compared
+// word by word to highlight what changed within them
+const intralineMinLength = 30
+
+// defaultDiffContext is the number of unchanged lines shown around each change
+const defaultDiffContext = 3
+
+// ANSI escape sequences used to color diffs
+const (
+	ansiReset     = "\x1b[0m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiCyan      = "\x1b[36m"
+	ansiBold      = "\x1b[1m"
+	ansiReverse   = "\x1b[7m"
+	ansiNoReverse = "\x1b[27m"
+)
+
+// diffOptions tune how unified diffs are rendered
+type diffOptions struct {
+	context   int  // unchanged lines around changes
+	color     bool // use ANSI colors
+	intraline bool // highlight changes within long lines
+}
+
+// defaultDiffOptions returns the diff options set in the environment
+func defaultDiffOptions() diffOptions {
+	opts := diffOptions{
+		context:   defaultDiffContext,
+		color:     useColor(),
+		intraline: true,
+	}
+	if n, err := strconv.Atoi(os.Getenv(ContextEnv)); err == nil && n >= 0 {
+		opts.context = n
+	}
+	if on, err := strconv.ParseBool(os.Getenv(IntralineEnv)); err == nil {
+		opts.intraline = on
+	}
+	return opts
+}
+
+// useColor tells whether output should be colored
+func useColor() bool {
+	switch os.Getenv(ColorEnv) {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// textDiff returns the differences between two texts as a unified diff,
+// or an empty string if they are equal
+func textDiff(expected, actual string) string {
+	return unifiedDiff(expected, actual, defaultDiffOptions())
+}
+
+// diffOp is an edit script step: keep, delete or insert a token
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	a, b int  // index of the token in each sequence, -1 if it is not there
+}
+
+// myersDiff returns a shortest edit script turning a into b, using Myers'
+// O(ND) algorithm after trimming the common prefix and suffix
+func myersDiff(a, b []string) []diffOp {
+	return myersAlign(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+}
+
+// myersAlign is myersDiff for sequences of n and m tokens whose tokens
+// i and j are kept together when equal(i, j) holds
+func myersAlign(n, m int, equal func(i, j int) bool) []diffOp {
+	prefix := 0
+	for prefix < n && prefix < m && equal(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && equal(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{' ', i, i})
+	}
+	middle := myersMiddle(n-prefix-suffix, m-prefix-suffix, func(i, j int) bool { return equal(prefix+i, prefix+j) })
+	for _, op := range middle {
+		if op.a >= 0 {
+			op.a += prefix
+		}
+		if op.b >= 0 {
+			op.b += prefix
+		}
+		ops = append(ops, op)
+	}
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, diffOp{' ', n - suffix + i, m - suffix + i})
+	}
+	return ops
+}
+
+func myersMiddle(n, m int, equal func(i, j int) bool) []diffOp {
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d-1..d+1] as it was before step d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(n, m)
+		}
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && equal(x, y) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return myersBacktrack(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// myersBacktrack walks the trace back from the end to build the edit script
+func myersBacktrack(trace [][]int, n, m int) []diffOp {
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v(k-1) < v(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', -1, y})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', x, -1})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceAll is the edit script deleting all n tokens and inserting all m
+func replaceAll(n, m int) []diffOp {
+	ops := make([]diffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffOp{'-', i, -1})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, diffOp{'+', -1, j})
+	}
+	return ops
+}
+
+// splitLines splits text keeping the line terminators, so that a missing
+// newline at the end of the text is also a difference
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff renders the line differences between two texts as a unified
+// diff with hunk headers, or returns an empty string if they are equal
+func unifiedDiff(expected, actual string, o diffOptions) string {
+	a, b := splitLines(expected), splitLines(actual)
+	ops := myersDiff(a, b)
+
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	if o.context < 0 {
+		o.context = 0
+	}
+
+	var sb strings.Builder
+	d := &diffWriter{sb: &sb, o: o, a: a, b: b}
+	d.header("--- expected\n", ansiBold)
+	d.header("+++ actual\n", ansiBold)
+	for start := 0; start < len(changes); {
+		// extend the hunk while changes are close enough to share context
+		end := start
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*o.context+1 {
+			end++
+		}
+		first := changes[start] - o.context
+		if first < 0 {
+			first = 0
+		}
+		last := changes[end] + o.context
+		if last >= len(ops) {
+			last = len(ops) - 1
+		}
+		d.hunk(ops[first:last+1], lineBefore(ops[:first], true), lineBefore(ops[:first], false))
+		start = end + 1
+	}
+	return sb.String()
+}
+
+// lineBefore counts the lines of a, or b, consumed by ops
+func lineBefore(ops []diffOp, a bool) int {
+	n := 0
+	for _, op := range ops {
+		if a && op.a >= 0 || !a && op.b >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// diffWriter renders hunks of an edit script between lines a and b
+type diffWriter struct {
+	sb   *strings.Builder
+	o    diffOptions
+	a, b []string
+}
+
+func (d *diffWriter) header(text, color string) {
+	if d.o.color {
+		text = color + strings.TrimSuffix(text, "\n") + ansiReset + "\n"
+	}
+	d.sb.WriteString(text)
+}
+
+// hunkRange formats the start,count part of a hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// an empty range points at the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// hunk writes a hunk whose first line is at aStart and bStart (0-based) in each text
+func (d *diffWriter) hunk(ops []diffOp, aStart, bStart int) {
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.a >= 0 {
+			aCount++
+		}
+		if op.b >= 0 {
+			bCount++
+		}
+	}
+	d.header(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(aStart+1, aCount), hunkRange(bStart+1, bCount)), ansiCyan)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			d.line(' ', d.a[ops[i].a], nil)
+			i++
+			continue
+		}
+		// a block of deletions followed by a block of insertions
+		var deleted, inserted []string
+		for ; i < len(ops) && ops[i].kind == '-'; i++ {
+			deleted = append(deleted, d.a[ops[i].a])
+		}
+		for ; i < len(ops) && ops[i].kind == '+'; i++ {
+			inserted = append(inserted, d.b[ops[i].b])
+		}
+		d.changes(deleted, inserted)
+	}
+}
+
+// changes writes a block of replaced lines, highlighting what changed within
+// pairs of long lines
+func (d *diffWriter) changes(deleted, inserted []string) {
+	var deletedMarks, insertedMarks [][]bool
+	if d.o.intraline {
+		for i := 0; i < len(deleted) && i < len(inserted); i++ {
+			if len(deleted[i]) >= intralineMinLength && len(inserted[i]) >= intralineMinLength {
+				dm, im := intralineMarks(deleted[i], inserted[i])
+				deletedMarks = append(deletedMarks, dm)
+				insertedMarks = append(insertedMarks, im)
+			} else {
+				deletedMarks = append(deletedMarks, nil)
+				insertedMarks = append(insertedMarks, nil)
+			}
+		}
+	}
+	for i, line := range deleted {
+		var marks []bool
+		if i < len(deletedMarks) {
+			marks = deletedMarks[i]
+		}
+		d.line('-', line, marks)
+	}
+	for i, line := range inserted {
+		var marks []bool
+		if i < len(insertedMarks) {
+			marks = insertedMarks[i]
+		}
+		d.line('+', line, marks)
+	}
+}
+
+// line writes a diff line. marks flags the bytes of line that changed;
+// they are shown in reverse video when coloring or with a line of carets otherwise
+func (d *diffWriter) line(kind byte, line string, marks []bool) {
+	text := strings.TrimSuffix(line, "\n")
+	color := ""
+	switch {
+	case !d.o.color:
+	case kind == '-':
+		color = ansiRed
+	case kind == '+':
+		color = ansiGreen
+	}
+
+	d.sb.WriteString(color)
+	d.sb.WriteByte(kind)
+	if color != "" && marks != nil {
+		highlighted := false
+		for i := 0; i < len(text); i++ {
+			if marks[i] != highlighted {
+				highlighted = marks[i]
+				if highlighted {
+					d.sb.WriteString(ansiReverse)
+				} else {
+					d.sb.WriteString(ansiNoReverse)
+				}
+			}
+			d.sb.WriteByte(text[i])
+		}
+	} else {
+		d.sb.WriteString(text)
+	}
+	if color != "" {
+		d.sb.WriteString(ansiReset)
+	}
+	d.sb.WriteByte('\n')
+
+	if color == "" && marks != nil {
+		carets := []byte{'?'}
+		for i := 0; i < len(text); i++ {
+			switch {
+			case marks[i]:
+				carets = append(carets, '^')
+			case text[i] == '\t':
+				carets = append(carets, '\t')
+			default:
+				carets = append(carets, ' ')
+			}
+		}
+		d.sb.WriteString(strings.TrimRight(string(carets), " \t") + "\n")
+	}
+	if !strings.HasSuffix(line, "\n") {
+		d.sb.WriteString("\\ No newline at end of file\n")
+	}
+}
+
+// splitWords splits a line into words, runs of spaces and single punctuation characters
+func splitWords(line string) []string {
+	var words []string
+	start := 0
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 3
+	}
+	prev := 0
+	for i, r := range line {
+		c := class(r)
+		if i > start && (c != prev || c == 3) {
+			words = append(words, line[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(line) {
+		words = append(words, line[start:])
+	}
+	return words
+}
+
+// intralineMarks flags the bytes of each line that are not part of the
+// words both lines share
+func intralineMarks(deleted, inserted string) (deletedMarks, insertedMarks []bool) {
+	a := splitWords(strings.TrimSuffix(deleted, "\n"))
+	b := splitWords(strings.TrimSuffix(inserted, "\n"))
+	deletedMarks = make([]bool, len(deleted))
+	insertedMarks = make([]bool, len(inserted))
+	aOffsets, bOffsets := wordOffsets(a), wordOffsets(b)
+	for _, op := range myersDiff(a, b) {
+		switch op.kind {
+		case '-':
+			for i := 0; i < len(a[op.a]); i++ {
+				deletedMarks[aOffsets[op.a]+i] = true
+			}
+		case '+':
+			for i := 0; i < len(b[op.b]); i++ {
+				insertedMarks[bOffsets[op.b]+i] = true
+			}
+		}
+	}
+	return deletedMarks, insertedMarks
+}
+
+// wordOffsets returns the byte offset of each word in the line they were split from
+func wordOffsets(words []string) []int {
+	offsets := make([]int, len(words))
+	offset := 0
+	for i, word := range words {
+		offsets[i] = offset
+		offset += len(word)
+	}
+	return offsets
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestTextDiff(t *testing.T) {
+	dir := tempTestdata(t)
+
+	var expected, actual []string
+	for i := 1; i <= 20; i++ {
+		expected = append(expected, fmt.Sprintf("line %d", i))
+		actual = append(actual, fmt.Sprintf("line %d", i))
+	}
+	actual[1] = "line 2 changed"
+	expected[15] = "the quick brown fox jumps over the lazy dog"
+	actual[15] = "the quick red fox jumps over the lazy dog"
+	actual = append(actual[:18], actual[19:]...)
+	err := ioutil.WriteFile(filepath.Join(dir, "text.txt"), []byte(strings.Join(expected, "\n")+"\n"), 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(ut.ColorEnv, ut.ColorNever)
+	defer os.Unsetenv(ut.ColorEnv)
+	ft, _, _ := MetaTester("TextDiff", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("text.txt", strings.Join(actual, "\n")+"\n")
+	})
+	output := ft.Output()
+	diff := `--- expected
++++ actual
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++line 2 changed
+ line 3
+ line 4
+ line 5
+@@ -13,8 +13,7 @@
+ line 13
+ line 14
+ line 15
+-the quick brown fox jumps over the lazy dog
+?          ^^^^^
++the quick red fox jumps over the lazy dog
+?          ^^^
+ line 17
+ line 18
+-line 19
+ line 20
+`
+	if !strings.Contains(output, diff) {
+		t.Fatalf("Expected output to contain the diff\n%s\ngot:\n%s", diff, output)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+)
+
+// EqualsDir checks if the regular files under actualDir are identical to those
+// under the indicated subfolder of the current test's testdata folder,
+// reporting missing and extra files, executable bit differences and per-file diffs.
+// When generating results, the golden subfolder is made a mirror of actualDir
+func (tt *TestTools) EqualsDir(goldenSubdir, actualDir string, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	goldenDir := filepath.Join(tt.TestdataDir, goldenSubdir)
+	actualFiles, err := listFiles(actualDir)
+	if err != nil {
+		tt.Fatalf("Cannot read directory %s : %s", actualDir, err)
+	}
+
+	if tt.shouldGenerate(goldenSubdir) {
+		for rel := range actualFiles {
+			tt.touchFile(filepath.Join(goldenSubdir, rel))
+		}
+		err := mirrorDir(goldenDir, actualDir, actualFiles)
+		if err != nil {
+			tt.Fatalf("Cannot write test result directory %s : %s", goldenDir, err)
+		}
+		return
+	}
+
+	goldenFiles, err := listFiles(goldenDir)
+	if err != nil {
+		tt.Fatalf("Cannot read test result directory %s : %s", goldenDir, err)
+	}
+	for rel := range goldenFiles {
+		tt.touchFile(filepath.Join(goldenSubdir, rel))
+	}
+	if tt.in().WithMessage(msgAndArgs...).NotDirEquals(0, goldenDir, actualDir) {
+		tt.Error(fmt.Errorf("Directories don't match. Check directory '%s' in testdata/%s", goldenSubdir, tt.T.Name()))
+	}
+}
+
+// NotDirEquals compares the regular files of two directory trees
+func (in *internal) NotDirEquals(callDepth int, expectedDir, actualDir string) bool {
+	helperOf(in.t)()
+	expectedFiles, err := listFiles(expectedDir)
+	if err != nil {
+		in.failf(callDepth, "\n\n\tCannot read directory %s: %s", expectedDir, err)
+		return true
+	}
+	actualFiles, err := listFiles(actualDir)
+	if err != nil {
+		in.failf(callDepth, "\n\n\tCannot read directory %s: %s", actualDir, err)
+		return true
+	}
+
+	var buf bytes.Buffer
+	for _, rel := range sortedFileNames(expectedFiles) {
+		if _, ok := actualFiles[rel]; !ok {
+			fmt.Fprintf(&buf, "\tmissing: %s\n", rel)
+		}
+	}
+	for _, rel := range sortedFileNames(actualFiles) {
+		if _, ok := expectedFiles[rel]; !ok {
+			fmt.Fprintf(&buf, "\textra: %s\n", rel)
+		}
+	}
+	for _, rel := range sortedFileNames(expectedFiles) {
+		actualInfo, ok := actualFiles[rel]
+		if !ok {
+			continue
+		}
+		expectedInfo := expectedFiles[rel]
+		if isExecutable(expectedInfo) != isExecutable(actualInfo) {
+			fmt.Fprintf(&buf, "\tmode: %s: expected %s, got %s\n", rel, expectedInfo.Mode(), actualInfo.Mode())
+		}
+		expected, err := ioutil.ReadFile(filepath.Join(expectedDir, rel))
+		if err != nil {
+			fmt.Fprintf(&buf, "\tcannot read %s: %s\n", rel, err)
+			continue
+		}
+		actual, err := ioutil.ReadFile(filepath.Join(actualDir, rel))
+		if err != nil {
+			fmt.Fprintf(&buf, "\tcannot read %s: %s\n", rel, err)
+			continue
+		}
+		if bytes.Equal(expected, actual) {
+			continue
+		}
+		fmt.Fprintf(&buf, "\tdiffers: %s\n", rel)
+		if isBinary(expected) || isBinary(actual) {
+			fmt.Fprintf(&buf, "\n%s\n", hexdumpDiff(expected, actual, firstDifference(expected, actual)))
+		} else if diff := textDiff(string(expected), string(actual)); diff != "" {
+			fmt.Fprintf(&buf, "\n%s\n", diff)
+		}
+	}
+	if buf.Len() == 0 {
+		return false
+	}
+	in.failf(callDepth, "\n\n\tdirectory %s doesn't match %s:\n\n%s", actualDir, expectedDir, buf.String())
+	return true
+}
+
+// listFiles returns the regular files under dir, indexed by their relative path
+func listFiles(dir string) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	return files, err
+}
+
+// mirrorDir makes goldenDir contain exactly the given files of actualDir
+func mirrorDir(goldenDir, actualDir string, actualFiles map[string]os.FileInfo) error {
+	goldenFiles, err := listFiles(goldenDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for rel := range goldenFiles {
+		if _, ok := actualFiles[rel]; !ok {
+			if err := os.Remove(filepath.Join(goldenDir, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+		}
+	}
+	for rel, info := range actualFiles {
+		data, err := ioutil.ReadFile(filepath.Join(actualDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(goldenDir, filepath.FromSlash(rel))
+		CreateDirectory(filepath.Dir(path))
+		mode := goldenMode(info)
+		if err := ioutil.WriteFile(path, data, mode); err != nil {
+			return err
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	removeEmptyDirs(goldenDir)
+	return nil
+}
+
+// removeEmptyDirs deletes the empty subfolders of dir
+func removeEmptyDirs(dir string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sub := filepath.Join(dir, entry.Name())
+			removeEmptyDirs(sub)
+			os.Remove(sub) // only succeeds if empty
+		}
+	}
+}
+
+func sortedFileNames(files map[string]os.FileInfo) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isExecutable tells whether any executable bit is set, which is the only
+// permission git keeps track of
+func isExecutable(info os.FileInfo) bool {
+	return info.Mode().Perm()&0111 != 0
+}
+
+// goldenMode returns the permissions a golden file mirroring the given file
+// is written with: those of every other golden file, plus the executable bit
+func goldenMode(info os.FileInfo) os.FileMode {
+	if isExecutable(info) {
+		return 0770
+	}
+	return 0660
+}
+
+// isBinary tells whether data should be displayed as a hexdump rather than text
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
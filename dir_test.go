@@ -0,0 +1,110 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		ut.CreateDirectory(filepath.Dir(path))
+		if err := ioutil.WriteFile(path, []byte(content), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEqualsDir(t *testing.T) {
+	testdata := tempTestdata(t)
+	actual := tempTestdata(t)
+
+	writeTree(t, actual, map[string]string{
+		"main.go":     "package main\n",
+		"sub/a.txt":   "a\nb\n",
+		"sub/b.bin":   "\x00\x01\x02",
+		"sub/c/d.txt": "d",
+	})
+	writeTree(t, filepath.Join(testdata, "tree"), map[string]string{
+		"stale.txt": "stale",
+	})
+
+	os.Setenv(ut.UpdateEnv, "EqualsDir/tree")
+	MetaTester("EqualsDir", func(tt *ut.TestTools) {
+		tt.TestdataDir = testdata
+		tt.EqualsDir("tree", actual)
+	})
+	os.Unsetenv(ut.UpdateEnv)
+	if _, err := os.Stat(filepath.Join(testdata, "tree", "stale.txt")); !os.IsNotExist(err) {
+		t.Fatalf("Expected stale golden files to be deleted")
+	}
+
+	ft, early, _ := MetaTester("EqualsDir", func(tt *ut.TestTools) {
+		tt.TestdataDir = testdata
+		tt.EqualsDir("tree", actual)
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected identical directories to pass")
+	}
+
+	for i, change := range []func(){
+		func() { writeTree(t, actual, map[string]string{"sub/a.txt": "a\nc\n"}) },
+		func() { writeTree(t, actual, map[string]string{"sub/b.bin": "\x00\x01\x03"}) },
+		func() { writeTree(t, actual, map[string]string{"extra.txt": "extra"}) },
+		func() { os.Remove(filepath.Join(actual, "sub/c/d.txt")) },
+		func() { os.Chmod(filepath.Join(actual, "main.go"), 0770) },
+	} {
+		change()
+		ft, early, _ := MetaTester("EqualsDir", func(tt *ut.TestTools) {
+			tt.TestdataDir = testdata
+			tt.EqualsDir("tree", actual)
+		})
+		if !early || !ft.fail {
+			t.Fatalf("#%d: Expected different directories to fail the test", i)
+		}
+		// regenerate the golden directory so the next change is the only difference
+		os.Setenv(ut.UpdateEnv, "EqualsDir/tree")
+		ft, early, _ = MetaTester("EqualsDir", func(tt *ut.TestTools) {
+			tt.TestdataDir = testdata
+			tt.EqualsDir("tree", actual)
+		})
+		os.Unsetenv(ut.UpdateEnv)
+		if early || ft.fail {
+			t.Fatalf("#%d: Expected updating the golden directory to pass, got:\n%s", i, ft.Output())
+		}
+	}
+
+	// golden files only keep the executable bit of the files they mirror
+	os.Chmod(filepath.Join(actual, "sub/a.txt"), 0600)
+	os.Setenv(ut.UpdateEnv, "EqualsDir/tree")
+	MetaTester("EqualsDir", func(tt *ut.TestTools) {
+		tt.TestdataDir = testdata
+		tt.EqualsDir("tree", actual)
+	})
+	os.Unsetenv(ut.UpdateEnv)
+	for name, mode := range map[string]os.FileMode{"main.go": 0770, "sub/a.txt": 0660} {
+		info, err := os.Stat(filepath.Join(testdata, "tree", name))
+		ut.Ok(t, err)
+		ut.Equals(t, mode, info.Mode().Perm(), name)
+	}
+}
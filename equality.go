@@ -0,0 +1,181 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unsafe"
+)
+
+// equalityOptions relax how Equals, EqualsKey and EqualsFile compare Go values
+type equalityOptions struct {
+	ignore           []goPathPattern
+	ignoreUnexported bool
+	equalMethods     bool
+	nilEqualsEmpty   bool
+	sortSlices       bool
+	comparers        map[reflect.Type]reflect.Value
+}
+
+// goPathPattern matches the Go paths of values being compared, see IgnoreFields
+type goPathPattern struct {
+	name string         // field name matched anywhere, or
+	re   *regexp.Regexp // full path, with [*] matching any index or key
+}
+
+// IgnoreFields makes comparisons of Go values skip the given fields. A name such
+// as "ID" skips fields with that name at any depth, while a path such as
+// ".Orders[*].ID" skips a specific one, [*] matching any index or map key
+func IgnoreFields(namesOrPaths ...string) Option {
+	return func(o *options) {
+		for _, s := range namesOrPaths {
+			if !strings.HasPrefix(s, ".") && !strings.HasPrefix(s, "[") {
+				o.equality.ignore = append(o.equality.ignore, goPathPattern{name: s})
+				continue
+			}
+			expr := strings.Replace(regexp.QuoteMeta(s), `\[\*\]`, `\[[^\]]*\]`, -1)
+			o.equality.ignore = append(o.equality.ignore, goPathPattern{re: regexp.MustCompile("^" + expr + "$")})
+		}
+	}
+}
+
+// IgnoreUnexported makes comparisons of Go values skip unexported struct fields
+func IgnoreUnexported() Option {
+	return func(o *options) {
+		o.equality.ignoreUnexported = true
+	}
+}
+
+// UseEqualMethods makes comparisons of Go values use the Equal method of types
+// having one with the form func (T) Equal(T) bool, such as time.Time, whose
+// values can otherwise differ in their location or monotonic clock reading
+func UseEqualMethods() Option {
+	return func(o *options) {
+		o.equality.equalMethods = true
+	}
+}
+
+// NilEqualsEmpty makes comparisons of Go values consider nil slices and maps
+// equal to empty ones
+func NilEqualsEmpty() Option {
+	return func(o *options) {
+		o.equality.nilEqualsEmpty = true
+	}
+}
+
+// SortSlices makes comparisons of Go values ignore the order of slice elements,
+// as if both slices were sorted before comparing them. Each expected element
+// is matched with an equal actual one, as per the rest of options, and those
+// left unmatched are reported as missing or unexpected
+func SortSlices() Option {
+	return func(o *options) {
+		o.equality.sortSlices = true
+	}
+}
+
+// AddComparer registers functions of the form func(a, b T) bool that decide
+// whether two values of type T are equal in the comparisons of Go values
+// made by Equals, EqualsKey and EqualsFile for the rest of the test
+func (tt *TestTools) AddComparer(comparers ...interface{}) {
+	for _, comparer := range comparers {
+		typ, err := comparerType(comparer)
+		if err != nil {
+			tt.Fatalf("Invalid comparer: %s", err)
+		}
+		if tt.comparers == nil {
+			tt.comparers = make(map[reflect.Type]reflect.Value)
+		}
+		tt.comparers[typ] = reflect.ValueOf(comparer)
+	}
+}
+
+// comparerType returns the type a comparer compares
+func comparerType(comparer interface{}) (reflect.Type, error) {
+	f := reflect.TypeOf(comparer)
+	if f == nil || f.Kind() != reflect.Func || f.NumIn() != 2 || f.In(0) != f.In(1) ||
+		f.NumOut() != 1 || f.Out(0).Kind() != reflect.Bool || f.IsVariadic() {
+		return nil, fmt.Errorf("expected a func(a, b T) bool, got %T", comparer)
+	}
+	return f.In(0), nil
+}
+
+// splitOptions separates the options given before the optional message
+// and arguments of a check
+func splitOptions(args []interface{}) ([]Option, []interface{}) {
+	var opts []Option
+	for len(args) > 0 {
+		opt, ok := args[0].(Option)
+		if !ok {
+			break
+		}
+		opts = append(opts, opt)
+		args = args[1:]
+	}
+	return opts, args
+}
+
+// relaxed tells whether any option makes values equal that reflect.DeepEqual doesn't
+func (o *equalityOptions) relaxed() bool {
+	return len(o.ignore) > 0 || o.ignoreUnexported || o.equalMethods || o.nilEqualsEmpty || o.sortSlices || len(o.comparers) > 0
+}
+
+// ignored tells whether the value at the given path is to be skipped
+func (o *equalityOptions) ignored(path string) bool {
+	for _, p := range o.ignore {
+		if p.re != nil && p.re.MatchString(path) || p.re == nil && strings.HasSuffix(path, "."+p.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// customEqual compares two values of the same type with a registered comparer
+// or, if enabled, their Equal method. ok is false if neither applies
+func (o *equalityOptions) customEqual(expected, actual reflect.Value) (equal, ok bool) {
+	typ := expected.Type()
+	f, ok := o.comparers[typ]
+	if !ok && o.equalMethods {
+		if m, found := typ.MethodByName("Equal"); found && m.Type.NumIn() == 2 && m.Type.In(1) == typ &&
+			m.Type.NumOut() == 1 && m.Type.Out(0).Kind() == reflect.Bool {
+			f, ok = m.Func, true
+		}
+	}
+	if !ok {
+		return false, false
+	}
+	e, eok := interfaceValue(expected)
+	a, aok := interfaceValue(actual)
+	if !eok || !aok {
+		return false, false
+	}
+	return f.Call([]reflect.Value{e, a})[0].Bool(), true
+}
+
+// interfaceValue returns v in a form that can be passed to functions,
+// even if it was reached through unexported fields, as long as it is addressable
+func interfaceValue(v reflect.Value) (reflect.Value, bool) {
+	if v.CanInterface() {
+		return v, true
+	}
+	if v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem(), true
+	}
+	return v, false
+}
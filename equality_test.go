@@ -0,0 +1,164 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type money struct {
+	cents int64
+}
+
+func (m money) Equal(other money) bool {
+	return m.cents/100 == other.cents/100
+}
+
+type invoice struct {
+	ID      int
+	Created time.Time
+	Total   money
+	Lines   []invoiceLine
+	Tags    map[string]string
+	secret  string
+}
+
+type invoiceLine struct {
+	ID  int
+	SKU string
+	Qty int
+}
+
+func TestEqualityOptions(t *testing.T) {
+	created := time.Now()
+	expected := invoice{
+		ID:      1,
+		Created: created.UTC(),
+		Total:   money{1050},
+		Lines:   []invoiceLine{{ID: 1, SKU: "a", Qty: 1}, {ID: 2, SKU: "b", Qty: 2}},
+		secret:  "x",
+	}
+	actual := invoice{
+		ID:      2,
+		Created: created,
+		Total:   money{1099},
+		Lines:   []invoiceLine{{ID: 7, SKU: "b", Qty: 2}, {ID: 8, SKU: "a", Qty: 1}},
+		Tags:    map[string]string{},
+		secret:  "y",
+	}
+
+	var tests = []struct {
+		name string
+		opts []interface{}
+		fail bool
+	}{
+		{"no options", nil, true},
+		{"all options", []interface{}{ut.IgnoreFields("ID"), ut.UseEqualMethods(), ut.NilEqualsEmpty(), ut.SortSlices(), ut.IgnoreUnexported()}, false},
+		{"all options with message", []interface{}{ut.IgnoreFields(".ID", ".Lines[*].ID"), ut.UseEqualMethods(), ut.NilEqualsEmpty(),
+			ut.SortSlices(), ut.IgnoreUnexported(), "invoice %d", 1}, false},
+		{"ordered slices", []interface{}{ut.IgnoreFields("ID"), ut.UseEqualMethods(), ut.NilEqualsEmpty(), ut.IgnoreUnexported()}, true},
+		{"top level ID only", []interface{}{ut.IgnoreFields(".ID"), ut.UseEqualMethods(), ut.NilEqualsEmpty(), ut.SortSlices(), ut.IgnoreUnexported()}, true},
+		{"nil map", []interface{}{ut.IgnoreFields("ID"), ut.UseEqualMethods(), ut.SortSlices(), ut.IgnoreUnexported()}, true},
+		{"unexported", []interface{}{ut.IgnoreFields("ID"), ut.UseEqualMethods(), ut.NilEqualsEmpty(), ut.SortSlices()}, true},
+		{"ignored unexported", []interface{}{ut.IgnoreFields("ID", "secret"), ut.UseEqualMethods(), ut.NilEqualsEmpty(), ut.SortSlices()}, false},
+	}
+
+	for _, test := range tests {
+		ft := new(fakeT)
+		ut.Equals(ft, expected, actual, test.opts...)
+		if ft.fail != test.fail {
+			t.Fatalf("%s: expected failure to be %v, got %v:\n%s", test.name, test.fail, ft.fail, ft.Output())
+		}
+	}
+
+	ft := new(fakeT)
+	ut.Equals(ft, expected, actual, ut.IgnoreFields("ID", "Created", "Total", "Tags", "secret"), "invoice %d", 1)
+	output := ft.Output()
+	if !strings.Contains(output, "invoice 1\n") || !strings.Contains(output, `.Lines[0].SKU: "a" != "b"`) {
+		t.Fatalf("Expected the options to be followed by the message, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.Equals(ft, []int{1, 2, 3}, []int{3, 1, 4}, ut.SortSlices())
+	if output := ft.Output(); !strings.Contains(output, "[1]: missing, expected 2\n") || !strings.Contains(output, "[2]: unexpected 4\n") {
+		t.Fatalf("Expected the unmatched elements to be reported, got:\n%s", output)
+	}
+}
+
+func TestAddComparer(t *testing.T) {
+	dir := tempTestdata(t)
+
+	type event struct {
+		Name string
+		At   time.Time
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	ft, early, _ := MetaTester("AddComparer", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.AddComparer(func(a, b time.Time) bool {
+			return a.Truncate(time.Minute).Equal(b.Truncate(time.Minute))
+		})
+		tt.Equals(event{"start", at}, event{"start", at.Add(time.Second).In(time.Local)})
+		tt.Results = map[string]json.RawMessage{"event": []byte(`{"Name": "start", "At": "2020-01-02T03:04:00Z"}`)}
+		tt.EqualsKey("event", event{"start", at})
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected the comparer to be used, got:\n%s", ft.Output())
+	}
+
+	ft, early, _ = MetaTester("AddComparer", func(tt *ut.TestTools) {
+		tt.AddComparer(func(a time.Time, b int) bool { return false })
+	})
+	if !early || !ft.fail || !strings.Contains(ft.Output(), "Invalid comparer: expected a func(a, b T) bool") {
+		t.Fatalf("Expected an invalid comparer to fail the test, got:\n%s", ft.Output())
+	}
+}
+
+func TestEqualityOptionsWithScrubbers(t *testing.T) {
+	type record struct {
+		ID   int
+		Ref  string
+		Tags []string
+	}
+	ft, early, _ := MetaTester("EqualityOptionsWithScrubbers", func(tt *ut.TestTools) {
+		tt.AddScrubber(ut.ScrubUUIDs())
+		tt.Results = map[string]json.RawMessage{"record": []byte(`{"ID": 1, "Ref": "<uuid-1>", "Tags": ["b", "a"]}`)}
+		tt.EqualsKey("record", record{2, "0b7cbfa6-5f4a-4cf4-a0b4-9a5c4b5d9f1e", []string{"a", "b"}},
+			ut.IgnoreFields("ID"), ut.SortSlices())
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected equality options to apply to scrubbed values, got:\n%s", ft.Output())
+	}
+
+	type event struct {
+		ID int
+		At time.Time
+	}
+	ft, early, _ = MetaTester("EqualityOptionsWithScrubbers", func(tt *ut.TestTools) {
+		tt.AddScrubber(ut.ScrubRFC3339())
+		tt.Results = map[string]json.RawMessage{"event": []byte(`{"ID": 1, "At": "<time-1>"}`)}
+		tt.EqualsKey("event", event{2, time.Now()}, ut.IgnoreFields("ID"))
+	})
+	if !early || !ft.fail || !strings.Contains(ft.Output(), "Cannot apply equality options or comparers to 'key:event'") {
+		t.Fatalf("Expected equality options on values that no longer fit their type to fail, got:\n%s", ft.Output())
+	}
+}
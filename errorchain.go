@@ -0,0 +1,185 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// MustFailIs checks that err or an error it wraps matches target, as per errors.Is.
+// Otherwise it fails the test, printing the chain of wrapped errors
+func (tt *TestTools) MustFailIs(err, target error, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotErrorIs(0, err, target) {
+		tt.Error(fmt.Errorf("Should have failed with: %s", Internal.ErrorString(target)))
+	}
+}
+
+// MustFailAs checks that err or an error it wraps can be assigned to the
+// value target points to, as per errors.As, which is then set to it.
+// Otherwise it fails the test, printing the chain of wrapped errors
+func (tt *TestTools) MustFailAs(err error, target interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotErrorAs(0, err, target) {
+		tt.Error(fmt.Errorf("Should have failed with a %T", target))
+	}
+}
+
+// MustFailMatching checks that err's message matches the given regular expression.
+// Otherwise it fails the test, printing the chain of wrapped errors
+func (tt *TestTools) MustFailMatching(err error, expr string, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotErrorMatches(0, err, expr) {
+		tt.Error(fmt.Errorf("Should have failed with an error matching %s", expr))
+	}
+}
+
+// EqualsErrorKey verifies that err's message is the one stored in the given
+// key of the current test's results.json. When generating results, the message
+// is stored instead. The test fails if err is nil
+func (tt *TestTools) EqualsErrorKey(key string, err error, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.Results == nil {
+		tt.Fatalf("To use EqualsErrorKey(), call LoadResults() first")
+	}
+	in := tt.in().WithMessage(msgAndArgs...)
+	if in.NotAssert(0, err != nil, "Expected an error") {
+		tt.Error(errors.New("Should have failed"))
+	}
+	tt.touchKey(key)
+	actual, _ := json.Marshal(err.Error())
+	if tt.shouldGenerate(key) {
+		tt.setResult(key, actual)
+		return
+	}
+	expectedBytes, ok := tt.result(key)
+	if !ok {
+		tt.Fatalf("Cannot find result key '%s'", key)
+	}
+	var expected string
+	if err := json.Unmarshal(expectedBytes, &expected); err != nil {
+		tt.Fatalf("Result key '%s' does not hold an error message: %s", key, err)
+	}
+	if in.NotErrorMessage(0, expected, err) {
+		tt.receiveKey(key, actual)
+		tt.Error(fmt.Errorf("Error messages don't match. Check key '%s' in testdata/%s/results.json", key, tt.T.Name()))
+	}
+}
+
+// NotErrorIs checks whether err or an error it wraps matches target, as per errors.Is
+func (in *internal) NotErrorIs(callDepth int, err, target error) bool {
+	helperOf(in.t)()
+	if !errors.Is(err, target) {
+		in.failf(callDepth, "\n\n\texpected error: %s\n\n\tgot: %s", errorWithType(target), errorChain(err))
+		return true
+	}
+	return false
+}
+
+// NotErrorAs checks whether err or an error it wraps can be assigned to the
+// value target points to, as per errors.As, setting it if so
+func (in *internal) NotErrorAs(callDepth int, err error, target interface{}) bool {
+	helperOf(in.t)()
+	typ := reflect.TypeOf(target)
+	if typ == nil || typ.Kind() != reflect.Ptr || reflect.ValueOf(target).IsNil() {
+		in.failf(callDepth, "MustFailAs: target must be a non-nil pointer, got %T", target)
+		return true
+	}
+	if typ.Elem().Kind() != reflect.Interface && !typ.Elem().Implements(errorType) {
+		in.failf(callDepth, "MustFailAs: target must point to an interface or to a type implementing error, got %T", target)
+		return true
+	}
+	if !errors.As(err, target) {
+		in.failf(callDepth, "\n\n\texpected error of type: %s\n\n\tgot: %s", typ.Elem(), errorChain(err))
+		return true
+	}
+	return false
+}
+
+// NotErrorMatches checks whether err's message matches the regular expression expr
+func (in *internal) NotErrorMatches(callDepth int, err error, expr string) bool {
+	helperOf(in.t)()
+	re, compileErr := regexp.Compile(expr)
+	if compileErr != nil {
+		in.failf(callDepth, "MustFailMatching: invalid regular expression %s: %s", expr, compileErr)
+		return true
+	}
+	if err == nil || !re.MatchString(err.Error()) {
+		in.failf(callDepth, "\n\n\texpected error matching: %s\n\n\tgot: %s", expr, errorChain(err))
+		return true
+	}
+	return false
+}
+
+// NotErrorMessage checks whether err's message is the expected one
+func (in *internal) NotErrorMessage(callDepth int, expected string, err error) bool {
+	helperOf(in.t)()
+	if err == nil || err.Error() != expected {
+		in.failf(callDepth, "\n\n\texpected error: %q\n\n\tgot: %s", expected, errorChain(err))
+		return true
+	}
+	return false
+}
+
+// errorWithType formats an error along with its type, e.g. *os.PathError "open x: no such file"
+func errorWithType(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%T %q", err, err.Error())
+}
+
+// errorChain formats err and the errors it wraps, one per line.
+// Errors joining several ones list them indented below
+func errorChain(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	b.WriteString(errorWithType(err))
+	writeWrappedErrors(&b, err, 0)
+	return b.String()
+}
+
+func writeWrappedErrors(b *strings.Builder, err error, depth int) {
+	indent := "\n\t" + strings.Repeat("  ", depth) + "wrapping "
+	for {
+		switch wrapper := err.(type) {
+		case interface{ Unwrap() error }:
+			if err = wrapper.Unwrap(); err == nil {
+				return
+			}
+			b.WriteString(indent + errorWithType(err))
+			continue
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range wrapper.Unwrap() {
+				if wrapped != nil {
+					b.WriteString(indent + errorWithType(wrapped))
+					writeWrappedErrors(b, wrapped, depth+1)
+				}
+			}
+		}
+		return
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type codeError struct {
+	Code int
+}
+
+func (e *codeError) Error() string {
+	return fmt.Sprintf("code %d", e.Code)
+}
+
+var errNotFound = errors.New("not found")
+
+func TestErrorChain(t *testing.T) {
+	err := fmt.Errorf("loading config: %w", fmt.Errorf("reading file: %w", errNotFound))
+	typed := fmt.Errorf("request failed: %w", &codeError{Code: 404})
+
+	ft, early, _ := MetaTester("ErrorChain", func(tt *ut.TestTools) {
+		tt.MustFailIs(err, errNotFound)
+		var ce *codeError
+		tt.MustFailAs(typed, &ce)
+		tt.Equals(404, ce.Code)
+		tt.MustFailMatching(err, `^loading config: .*not found$`)
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected wrapped errors to match, got:\n%s", ft.Output())
+	}
+
+	ft = new(fakeT)
+	ut.MustFailIs(ft, typed, errNotFound)
+	if !ft.fail {
+		t.Fatalf("Expected an error not wrapping the target to fail the test")
+	}
+	expected := "expected error: *errors.errorString \"not found\"\n\n" +
+		"\tgot: *fmt.wrapError \"request failed: code 404\"\n" +
+		"\twrapping *ut_test.codeError \"code 404\""
+	if output := ft.Output(); !strings.Contains(output, expected) {
+		t.Fatalf("Expected the error chain to be printed, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	var ce *codeError
+	ut.MustFailAs(ft, err, &ce)
+	if !ft.fail || !strings.Contains(ft.Output(), "expected error of type: *ut_test.codeError") {
+		t.Fatalf("Expected an error not wrapping the type to fail the test, got:\n%s", ft.Output())
+	}
+
+	ft = new(fakeT)
+	ut.MustFailAs(ft, err, ce)
+	if !ft.fail || !strings.Contains(ft.Output(), "target must be a non-nil pointer") {
+		t.Fatalf("Expected a nil target to fail the test, got:\n%s", ft.Output())
+	}
+
+	ft = new(fakeT)
+	ut.MustFailMatching(ft, nil, "not found")
+	if !ft.fail || !strings.Contains(ft.Output(), "got: <nil>") {
+		t.Fatalf("Expected a nil error to fail the test, got:\n%s", ft.Output())
+	}
+}
+
+func TestEqualsErrorKey(t *testing.T) {
+	dir := tempTestdata(t)
+
+	err := fmt.Errorf("loading config: %w", errNotFound)
+	ft, early, _ := MetaTester("EqualsErrorKey", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"load": []byte(`"loading config: not found"`)}
+		tt.EqualsErrorKey("load", err)
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected the error message to match, got:\n%s", ft.Output())
+	}
+
+	ft, early, _ = MetaTester("EqualsErrorKey", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"load": []byte(`"loading config: timeout"`)}
+		tt.EqualsErrorKey("load", err)
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected a different error message to fail the test")
+	}
+	if output := ft.Output(); !strings.Contains(output, "expected error: \"loading config: timeout\"") ||
+		!strings.Contains(output, "wrapping *errors.errorString \"not found\"") {
+		t.Fatalf("Expected the error chain to be printed, got:\n%s", output)
+	}
+}
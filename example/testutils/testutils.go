@@ -39,10 +39,11 @@ func BeginTest(tb testing.TB, generateResults bool) *ExampleTestTools {
 }
 
 func (ett *ExampleTestTools) IsLongString(st string) {
+	// mark this function as a helper so failures point to the line calling it,
+	// otherwise the error message would always refer to this function, not very useful.
+	ett.Helper()
 	if len(st) < 100 {
-		// use Internal methods to correctly print the line where the error took place,
-		// otherwise the error message would always refer to this function, not very useful.
-		ut.Internal.Fatalf(0, "Expected the string to be long, got string of length=%d", len(st))
+		ut.Internal.For(ett.T).Fatalf(0, "Expected the string to be long, got string of length=%d", len(st))
 		ett.FailNow()
 	}
 }
@@ -0,0 +1,115 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// LoadFile returns the contents of an input file in the current test's
+// testdata folder or, if it is not there, in the package's shared testdata
+// folder. The test fails if the file cannot be found
+func (tt *TestTools) LoadFile(name string) []byte {
+	helperOf(tt.T)()
+	return tt.loadFile(0, name)
+}
+
+// LoadText returns the contents of an input file as a string, see LoadFile
+func (tt *TestTools) LoadText(name string) string {
+	helperOf(tt.T)()
+	return string(tt.loadFile(0, name))
+}
+
+// LoadJSON decodes the JSON input file into v, see LoadFile.
+// The test fails if the file is not valid JSON or does not fit v
+func (tt *TestTools) LoadJSON(name string, v interface{}) {
+	helperOf(tt.T)()
+	data := tt.loadFile(0, name)
+	if err := json.Unmarshal(data, v); err != nil {
+		tt.fatalf(0, "Cannot decode input file '%s': %s", name, jsonErrorPosition(data, err))
+	}
+}
+
+// LoadKey decodes the given key of the current test's results.json into v.
+// The test fails if the key does not exist or does not fit v
+func (tt *TestTools) LoadKey(key string, v interface{}) {
+	helperOf(tt.T)()
+	tt.touchKey(key)
+	data, ok := tt.result(key)
+	if !ok {
+		tt.fatalf(0, "Cannot find input key '%s' in testdata/%s/results.json", key, tt.T.Name())
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		tt.fatalf(0, "Cannot decode input key '%s': %s", key, jsonErrorPosition(data, err))
+	}
+}
+
+func (tt *TestTools) loadFile(callDepth int, name string) []byte {
+	helperOf(tt.T)()
+	path := filepath.Join(tt.TestdataDir, name)
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		tt.touchFile(name)
+		return data
+	}
+	if !os.IsNotExist(err) || tt.SharedTestdataDir == "" {
+		tt.fatalf(callDepth+1, "Cannot read input file %s : %s", path, err)
+	}
+	sharedPath := filepath.Join(tt.SharedTestdataDir, name)
+	data, err = ioutil.ReadFile(sharedPath)
+	if os.IsNotExist(err) {
+		tt.fatalf(callDepth+1, "Cannot find input file '%s' in %s or %s", name, tt.TestdataDir, tt.SharedTestdataDir)
+	}
+	if err != nil {
+		tt.fatalf(callDepth+1, "Cannot read input file %s : %s", sharedPath, err)
+	}
+	return data
+}
+
+// fatalf fails the test immediately, reporting the location of the
+// caller callDepth levels above the function calling fatalf
+func (tt *TestTools) fatalf(callDepth int, formatString string, args ...interface{}) {
+	helperOf(tt.T)()
+	tt.in().Fatalf(callDepth+1, formatString, args...)
+	tt.Error(errors.New("Fatal error"))
+}
+
+// jsonErrorPosition adds the line and column where decoding data failed
+// to JSON syntax and type errors
+func jsonErrorPosition(data []byte, err error) string {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err.Error()
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n') - 1
+	return fmt.Sprintf("line %d, column %d: %s", line, column, err)
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestLoadFixtures(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	var op struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+	t.LoadJSON("input.json", &op)
+	t.Equals(3, op.A+op.B)
+
+	var n int
+	t.LoadKey("n", &n)
+	t.Equals(42, n)
+
+	t.Equals("shared input\n", t.LoadText("shared.txt")) // found in the shared testdata folder
+	t.EqualsKey("sum", op.A+op.B)
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	for _, load := range []func(tt *ut.TestTools){
+		func(tt *ut.TestTools) { tt.LoadFile("missing.txt") },
+		func(tt *ut.TestTools) { tt.LoadJSON("shared.txt", new(int)) },
+		func(tt *ut.TestTools) { tt.LoadKey("missing", new(int)) },
+	} {
+		ft, early, _ := MetaTester("LoadFixturesErrors", load)
+		if !early || !ft.fail {
+			t.Fatalf("Expected missing or malformed inputs to fail the test")
+		}
+	}
+}
@@ -1,5 +1,3 @@
 module github.com/epiclabs-io/ut
 
 go 1.14
-
-require github.com/epiclabs-io/diff3 v0.0.0-20181217103619-05282cece609
@@ -0,0 +1,338 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// maxGoDifferences is how many differences between Go values are printed
+// unless verbose output is requested
+const maxGoDifferences = 20
+
+// goDifference is a difference found between two Go values, at the Go
+// path of the value, e.g. .Orders[2].Lines[0].Qty
+type goDifference struct {
+	path     string
+	expected string // rendered values
+	actual   string
+	missing  bool // only in the expected value
+	extra    bool // only in the actual value
+}
+
+// goComparison walks two values in parallel the same way reflect.DeepEqual
+// does, collecting every difference instead of stopping at the first one
+type goComparison struct {
+	differences []goDifference
+	visited     map[goVisit]bool
+	epsilon     float64 // floats differing by at most epsilon are equal
+	equality    *equalityOptions
+}
+
+// goVisit identifies a pair of pointers, maps or slices being compared, to cut cycles
+type goVisit struct {
+	expected, actual uintptr
+	typ              reflect.Type
+}
+
+// compareGo returns the differences between two values, in the order they
+// are found. Struct fields are visited in declaration order and map keys sorted.
+// Floats are considered equal if they differ by at most the FloatEpsilon option,
+// and the rest of options can relax the comparison further
+func compareGo(expected, actual interface{}, o *options) []goDifference {
+	c := &goComparison{visited: make(map[goVisit]bool), epsilon: o.json.epsilon, equality: &o.equality}
+	c.compare("", addressable(reflect.ValueOf(expected)), addressable(reflect.ValueOf(actual)))
+	return c.differences
+}
+
+// addressable returns an addressable copy of v, so times in unexported fields can be printed
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	a := reflect.New(v.Type()).Elem()
+	a.Set(v)
+	return a
+}
+
+func (c *goComparison) differ(path string, expected, actual reflect.Value) {
+	c.differences = append(c.differences, goDifference{
+		path:     path,
+		expected: formatGoValue(expected),
+		actual:   formatGoValue(actual),
+	})
+}
+
+// seen tells whether the pair of references was already compared, marking it otherwise
+func (c *goComparison) seen(expected, actual reflect.Value) bool {
+	key := goVisit{expected.Pointer(), actual.Pointer(), expected.Type()}
+	if c.visited[key] {
+		return true
+	}
+	c.visited[key] = true
+	return false
+}
+
+// addDifference records a value only one of the compared values has, unless ignored
+func (c *goComparison) addDifference(d goDifference) {
+	if !c.equality.ignored(d.path) {
+		c.differences = append(c.differences, d)
+	}
+}
+
+func (c *goComparison) compare(path string, expected, actual reflect.Value) {
+	if c.equality.ignored(path) {
+		return
+	}
+	if !expected.IsValid() || !actual.IsValid() {
+		if expected.IsValid() != actual.IsValid() {
+			c.differ(path, expected, actual)
+		}
+		return
+	}
+	if expected.Type() == actual.Type() {
+		if equal, ok := c.equality.customEqual(expected, actual); ok {
+			if !equal {
+				c.differ(path, expected, actual)
+			}
+			return
+		}
+	}
+	if expected.Type() != actual.Type() || expected.Type() == timeType {
+		if expected.Type() != actual.Type() || !reflect.DeepEqual(readable(expected), readable(actual)) {
+			c.differ(path, expected, actual)
+		}
+		return
+	}
+
+	switch expected.Kind() {
+	case reflect.Ptr:
+		if expected.Pointer() == actual.Pointer() {
+			return
+		}
+		if expected.IsNil() || actual.IsNil() {
+			c.differ(path, expected, actual)
+			return
+		}
+		if c.seen(expected, actual) {
+			return
+		}
+		c.compare(path, expected.Elem(), actual.Elem())
+	case reflect.Interface:
+		if expected.IsNil() || actual.IsNil() {
+			if expected.IsNil() != actual.IsNil() {
+				c.differ(path, expected, actual)
+			}
+			return
+		}
+		c.compare(path, expected.Elem(), actual.Elem())
+	case reflect.Struct:
+		for i := 0; i < expected.NumField(); i++ {
+			field := expected.Type().Field(i)
+			if field.PkgPath != "" && c.equality.ignoreUnexported {
+				continue
+			}
+			c.compare(path+"."+field.Name, expected.Field(i), actual.Field(i))
+		}
+	case reflect.Slice:
+		if expected.IsNil() != actual.IsNil() && !c.bothEmpty(expected, actual) {
+			c.differ(path, expected, actual)
+			return
+		}
+		if expected.Pointer() == actual.Pointer() && expected.Len() == actual.Len() || c.seen(expected, actual) {
+			return
+		}
+		if c.equality.sortSlices {
+			c.compareUnordered(path, indexedElements(expected), indexedElements(actual))
+			return
+		}
+		c.compareElements(path, indexedElements(expected), indexedElements(actual))
+	case reflect.Array:
+		c.compareElements(path, indexedElements(expected), indexedElements(actual))
+	case reflect.Map:
+		if expected.IsNil() != actual.IsNil() && !c.bothEmpty(expected, actual) {
+			c.differ(path, expected, actual)
+			return
+		}
+		if expected.Pointer() == actual.Pointer() || c.seen(expected, actual) {
+			return
+		}
+		for _, key := range sortedMapKeyValues(expected) {
+			keyPath := path + "[" + formatGoValue(key) + "]"
+			if value := actual.MapIndex(key); value.IsValid() {
+				c.compare(keyPath, expected.MapIndex(key), value)
+			} else {
+				c.addDifference(goDifference{path: keyPath, expected: formatGoValue(expected.MapIndex(key)), missing: true})
+			}
+		}
+		for _, key := range sortedMapKeyValues(actual) {
+			if !expected.MapIndex(key).IsValid() {
+				keyPath := path + "[" + formatGoValue(key) + "]"
+				c.addDifference(goDifference{path: keyPath, actual: formatGoValue(actual.MapIndex(key)), extra: true})
+			}
+		}
+	case reflect.Func:
+		// as for reflect.DeepEqual, functions are only equal if both are nil
+		if !expected.IsNil() || !actual.IsNil() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Chan, reflect.UnsafePointer:
+		if expected.Pointer() != actual.Pointer() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Bool:
+		if expected.Bool() != actual.Bool() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if expected.Int() != actual.Int() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if expected.Uint() != actual.Uint() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Float32, reflect.Float64:
+		if e, a := expected.Float(), actual.Float(); e != a && !(math.Abs(e-a) <= c.epsilon) {
+			c.differ(path, expected, actual)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		if expected.Complex() != actual.Complex() {
+			c.differ(path, expected, actual)
+		}
+	case reflect.String:
+		if expected.String() != actual.String() {
+			c.differ(path, expected, actual)
+		}
+	}
+}
+
+// compareElements compares the elements of arrays or slices one by one,
+// reporting the trailing elements only one of them has as missing or extra
+func (c *goComparison) compareElements(path string, expected, actual []reflect.Value) {
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		indexPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(actual):
+			c.addDifference(goDifference{path: indexPath, expected: formatGoValue(expected[i]), missing: true})
+		case i >= len(expected):
+			c.addDifference(goDifference{path: indexPath, actual: formatGoValue(actual[i]), extra: true})
+		default:
+			c.compare(indexPath, expected[i], actual[i])
+		}
+	}
+}
+
+// compareUnordered matches each expected element with an equal actual one,
+// reporting the elements left unmatched at their index in their slice
+func (c *goComparison) compareUnordered(path string, expected, actual []reflect.Value) {
+	matched := make([]bool, len(actual))
+	for i, e := range expected {
+		found := false
+		for j, a := range actual {
+			if !matched[j] && c.equal(path+"[*]", e, a) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			c.addDifference(goDifference{path: path + "[" + strconv.Itoa(i) + "]", expected: formatGoValue(e), missing: true})
+		}
+	}
+	for j, a := range actual {
+		if !matched[j] {
+			c.addDifference(goDifference{path: path + "[" + strconv.Itoa(j) + "]", actual: formatGoValue(a), extra: true})
+		}
+	}
+}
+
+// equal tells whether two values at path have no differences, with the same options
+func (c *goComparison) equal(path string, expected, actual reflect.Value) bool {
+	sub := &goComparison{visited: make(map[goVisit]bool), epsilon: c.epsilon, equality: c.equality}
+	sub.compare(path, expected, actual)
+	return len(sub.differences) == 0
+}
+
+// indexedElements returns the elements of an array or slice
+func indexedElements(v reflect.Value) []reflect.Value {
+	elements := make([]reflect.Value, v.Len())
+	for i := range elements {
+		elements[i] = v.Index(i)
+	}
+	return elements
+}
+
+// bothEmpty tells whether a nil and an empty slice or map are to be considered equal
+func (c *goComparison) bothEmpty(expected, actual reflect.Value) bool {
+	return c.equality.nilEqualsEmpty && expected.Len() == 0 && actual.Len() == 0
+}
+
+// readable returns the value held by v, even if it was reached through an
+// unexported field, as long as it is addressable
+func readable(v reflect.Value) interface{} {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	if v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem().Interface()
+	}
+	return formatGoValue(v)
+}
+
+// String describes the difference, e.g. ".Orders[2].Lines[0].Qty: 3 != 4"
+func (d *goDifference) String() string {
+	path := d.path
+	if path == "" {
+		path = "(root)"
+	}
+	switch {
+	case d.missing:
+		return fmt.Sprintf("%s: missing, expected %s", path, d.expected)
+	case d.extra:
+		return fmt.Sprintf("%s: unexpected %s", path, d.actual)
+	}
+	return fmt.Sprintf("%s: %s != %s", path, d.expected, d.actual)
+}
+
+// formatGoDifferences lists the differences one per line, capping their
+// number unless all of them were requested
+func formatGoDifferences(differences []goDifference, all bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\tvalues differ in %s (expected != got):\n", places(len(differences)))
+	for i := range differences {
+		if i == maxGoDifferences && !all {
+			fmt.Fprintf(&b, "\t... and %d more. Set UT_VERBOSE to see them all along with the full values\n",
+				len(differences)-maxGoDifferences)
+			break
+		}
+		fmt.Fprintf(&b, "\t%s\n", differences[i].String())
+	}
+	return b.String()
+}
+
+// places counts the places where two values differ, e.g. "1 place" or "3 places"
+func places(n int) string {
+	if n == 1 {
+		return "1 place"
+	}
+	return fmt.Sprintf("%d places", n)
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type orderLine struct {
+	Item string
+	Qty  int
+}
+
+type order struct {
+	ID      int
+	Lines   []orderLine
+	Tags    map[string]int
+	Parent  *order
+	created time.Time
+}
+
+func TestEqualsDifferences(t *testing.T) {
+	newOrder := func(qty int, tags map[string]int) *order {
+		o := &order{
+			ID:      7,
+			Lines:   []orderLine{{"apple", 1}, {"pear", qty}},
+			Tags:    tags,
+			created: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		o.Parent = o // cycles must not hang the comparison
+		return o
+	}
+	expected := newOrder(3, map[string]int{"x": 1, "y": 2})
+	actual := newOrder(4, map[string]int{"y": 2, "z": 3})
+	actual.Lines = append(actual.Lines, orderLine{"plum", 1})
+	actual.created = actual.created.Add(time.Second)
+
+	ft := new(fakeT)
+	ut.Equals(ft, expected, actual)
+	output := ft.Output()
+	for _, line := range []string{
+		"\tvalues differ in 5 places (expected != got):\n",
+		"\t.Lines[1].Qty: 3 != 4\n",
+		"\t.Lines[2]: unexpected ut_test.orderLine{Item: \"plum\", Qty: 1}\n",
+		"\t.Tags[\"x\"]: missing, expected 1\n",
+		"\t.Tags[\"z\"]: unexpected 3\n",
+		"\t.created: time.Time(\"2018-01-01T00:00:00Z\") != time.Time(\"2018-01-01T00:00:01Z\")\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	ft = new(fakeT)
+	ut.Equals(ft, newOrder(3, nil), newOrder(4, nil))
+	if output = ft.Output(); !strings.Contains(output, "\tvalues differ in 1 place (expected != got):\n") {
+		t.Fatalf("Expected a single difference to be counted in singular, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.Equals(ft, 3, 4)
+	output = ft.Output()
+	if !strings.Contains(output, "\texpected: 3\n\n\tgot: 4\n") {
+		t.Fatalf("Expected plain values to be printed as before, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.Equals(ft, newOrder(3, nil), newOrder(3, nil))
+	if ft.fail {
+		t.Fatalf("Expected equal values with cycles to be equal")
+	}
+}
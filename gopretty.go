@@ -0,0 +1,245 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// goPrinter renders any value in a deterministic, Go-like syntax, one field
+// or element per line. Maps are sorted by key and cycles are cut short
+type goPrinter struct {
+	buf     bytes.Buffer
+	visited map[visit]bool
+	compact bool // print everything in a single line
+}
+
+// visit identifies a pointer or map being printed, to detect cycles
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// formatGo renders v as indented Go-like syntax
+func formatGo(v interface{}) string {
+	p := &goPrinter{visited: make(map[visit]bool)}
+	p.print(addressable(reflect.ValueOf(v)), 0)
+	return p.buf.String()
+}
+
+// formatGoValue renders v in a single line, which is truncated if too long
+func formatGoValue(v reflect.Value) string {
+	p := &goPrinter{visited: make(map[visit]bool), compact: true}
+	p.print(v, 0)
+	if p.buf.Len() > maxJSONValueLength {
+		return string(p.buf.Bytes()[:maxJSONValueLength]) + "..."
+	}
+	return p.buf.String()
+}
+
+func (p *goPrinter) indent(depth int) {
+	if !p.compact {
+		p.buf.WriteString(strings.Repeat("\t", depth))
+	}
+}
+
+// open starts a composite literal
+func (p *goPrinter) open(typ string) {
+	p.buf.WriteString(typ + "{")
+	if !p.compact {
+		p.buf.WriteString("\n")
+	}
+}
+
+// next ends an element of a composite literal
+func (p *goPrinter) next(last bool) {
+	switch {
+	case !p.compact:
+		p.buf.WriteString(",\n")
+	case !last:
+		p.buf.WriteString(", ")
+	}
+}
+
+func (p *goPrinter) print(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		p.buf.WriteString("nil")
+		return
+	}
+
+	switch {
+	case v.Type() == timeType && (v.CanInterface() || v.CanAddr()):
+		if !v.CanInterface() {
+			v = reflect.NewAt(timeType, unsafe.Pointer(v.UnsafeAddr())).Elem()
+		}
+		p.buf.WriteString("time.Time(" + strconv.Quote(v.Interface().(time.Time).Format(time.RFC3339Nano)) + ")")
+		return
+	case v.Type() == durationType:
+		p.buf.WriteString("time.Duration(" + time.Duration(v.Int()).String() + ")")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		p.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.literal(v, strconv.FormatInt(v.Int(), 10), "int")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.literal(v, strconv.FormatUint(v.Uint(), 10), "uint")
+	case reflect.Float32, reflect.Float64:
+		p.literal(v, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), "float64")
+	case reflect.Complex64, reflect.Complex128:
+		p.buf.WriteString(fmt.Sprintf("%s%v", v.Type(), v.Complex()))
+	case reflect.String:
+		p.literal(v, strconv.Quote(v.String()), "string")
+	case reflect.Ptr:
+		if v.IsNil() {
+			p.buf.WriteString("(" + v.Type().String() + ")(nil)")
+			return
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if p.visited[key] {
+			p.buf.WriteString("&<cycle " + v.Type().Elem().String() + ">")
+			return
+		}
+		p.visited[key] = true
+		p.buf.WriteString("&")
+		p.print(v.Elem(), depth)
+		delete(p.visited, key)
+	case reflect.Interface:
+		if v.IsNil() {
+			p.buf.WriteString("nil")
+			return
+		}
+		p.print(v.Elem(), depth)
+	case reflect.Struct:
+		t := v.Type()
+		if t.NumField() == 0 {
+			p.buf.WriteString(t.String() + "{}")
+			return
+		}
+		p.open(t.String())
+		for i := 0; i < t.NumField(); i++ {
+			p.indent(depth + 1)
+			p.buf.WriteString(t.Field(i).Name + ": ")
+			p.print(v.Field(i), depth+1)
+			p.next(i == t.NumField()-1)
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			p.buf.WriteString(v.Type().String() + "(nil)")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			p.buf.WriteString(v.Type().String() + "(" + strconv.Quote(string(v.Bytes())) + ")")
+			return
+		}
+		if v.Len() == 0 {
+			p.buf.WriteString(v.Type().String() + "{}")
+			return
+		}
+		p.open(v.Type().String())
+		for i := 0; i < v.Len(); i++ {
+			p.indent(depth + 1)
+			p.print(v.Index(i), depth+1)
+			p.next(i == v.Len()-1)
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			p.buf.WriteString(v.Type().String() + "(nil)")
+			return
+		}
+		if v.Len() == 0 {
+			p.buf.WriteString(v.Type().String() + "{}")
+			return
+		}
+		key := visit{v.Pointer(), v.Type()}
+		if p.visited[key] {
+			p.buf.WriteString("<cycle " + v.Type().String() + ">")
+			return
+		}
+		p.visited[key] = true
+		p.open(v.Type().String())
+		keys := sortedMapKeyValues(v)
+		for i, key := range keys {
+			p.indent(depth + 1)
+			p.print(key, depth+1)
+			p.buf.WriteString(": ")
+			p.print(v.MapIndex(key), depth+1)
+			p.next(i == len(keys)-1)
+		}
+		p.indent(depth)
+		p.buf.WriteString("}")
+		delete(p.visited, key)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			p.buf.WriteString("(" + v.Type().String() + ")(nil)")
+		} else {
+			p.buf.WriteString("(" + v.Type().String() + ")(<non-nil>)")
+		}
+	default:
+		p.buf.WriteString(v.Type().String())
+	}
+}
+
+// literal writes a basic value, qualifying it with its type unless
+// it is the default type of the literal
+func (p *goPrinter) literal(v reflect.Value, text, defaultType string) {
+	if v.Type().String() == defaultType {
+		p.buf.WriteString(text)
+		return
+	}
+	p.buf.WriteString(v.Type().String() + "(" + text + ")")
+}
+
+// sortedMapKeyValues returns the keys of a map sorted by their rendering,
+// so maps are always printed in the same order
+func sortedMapKeyValues(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	rendered := make(map[int]string, len(keys))
+	order := make([]int, len(keys))
+	for i, key := range keys {
+		kp := &goPrinter{visited: make(map[visit]bool)}
+		kp.print(key, 0)
+		rendered[i] = kp.buf.String()
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return rendered[order[i]] < rendered[order[j]]
+	})
+	sorted := make([]reflect.Value, len(keys))
+	for i, k := range order {
+		sorted[i] = keys[k]
+	}
+	return sorted
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// maxStackDepth bounds how many frames are inspected to find where a check was made
+const maxStackDepth = 64
+
+var helpers struct {
+	sync.RWMutex
+	names map[string]bool
+}
+
+// moduleRoots caches the module root of source directories, "" if they have none
+var moduleRoots sync.Map
+
+// packagePrefix is the prefix of the names of this package's functions
+var packagePrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name() // e.g. github.com/epiclabs-io/ut.glob..func1
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+1+strings.Index(name[slash+1:], ".")+1]
+}()
+
+// Helper marks the calling function as a test helper, like testing.T.Helper
+// does. Failures are then reported at the line calling the helper rather
+// than inside it, without computing call depths by hand
+func Helper() {
+	registerHelper()
+}
+
+// Helper marks the calling function as a test helper, see ut.Helper.
+// The test's own Helper method is called too, if it has one
+func (tt *TestTools) Helper() {
+	registerHelper()
+	if h, ok := tt.T.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+}
+
+// helperOf returns the Helper method of t, if it has one, or a no-op. The
+// functions of this package call the result first thing, so that the
+// location t adds to the failures they log is that of the caller, as
+// testing.T.Helper skips the functions calling it. A method value must be
+// called, since Helper marks the function it is called from. FinishTest and
+// the functions it calls don't, since when it runs deferred during
+// runtime.Goexit the caller would be the runtime
+func helperOf(t T) func() {
+	if h, ok := t.(interface{ Helper() }); ok {
+		return h.Helper
+	}
+	return func() {}
+}
+
+// registerHelper records the first function calling into this package as a helper
+func registerHelper() {
+	pc := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		if !isLibraryFrame(frame) {
+			helpers.Lock()
+			if helpers.names == nil {
+				helpers.names = make(map[string]bool)
+			}
+			helpers.names[frame.Function] = true
+			helpers.Unlock()
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// isLibraryFrame tells whether a frame belongs to this package or to a
+// wrapper generated by the compiler for an embedded method
+func isLibraryFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, packagePrefix) || frame.File == "<autogenerated>"
+}
+
+func isHelper(function string) bool {
+	helpers.RLock()
+	defer helpers.RUnlock()
+	return helpers.names[function]
+}
+
+// callerLocation returns where a check was made: the frame skip levels
+// above the caller of callerLocation or, if that frame belongs to this
+// package or to a registered helper, the first frame above it that doesn't
+func callerLocation(skip int) (file string, line int) {
+	pc := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(skip+2, pc)])
+	first := true
+	for {
+		frame, more := frames.Next()
+		if first {
+			file, line, first = frame.File, frame.Line, false
+		}
+		if !isLibraryFrame(frame) && !isHelper(frame.Function) {
+			return frame.File, frame.Line
+		}
+		if !more {
+			return file, line
+		}
+	}
+}
+
+// relativePath returns the path of a source file relative to the root of its
+// module, so that it can be followed from editors and IDEs
+func relativePath(file string) string {
+	root := moduleRoot(filepath.Dir(file))
+	if root == "" {
+		return file
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// moduleRoot returns the closest directory containing a go.mod file
+func moduleRoot(dir string) string {
+	if root, ok := moduleRoots.Load(dir); ok {
+		return root.(string)
+	}
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = moduleRoot(parent)
+	}
+	moduleRoots.Store(dir, root)
+	return root
+}
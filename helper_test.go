@@ -0,0 +1,108 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func assertPositive(tt *ut.TestTools, n int) {
+	tt.Helper()
+	tt.Assert(n > 0, "%d is not positive", n)
+}
+
+func assertAllPositive(tt *ut.TestTools, numbers ...int) {
+	tt.Helper()
+	for _, n := range numbers {
+		assertPositive(tt, n)
+	}
+}
+
+func assertShort(t ut.T, s string) {
+	ut.Helper()
+	ut.Assert(t, len(s) < 5, "%q is too long", s)
+}
+
+func TestHelper(t *testing.T) {
+	sink := new(failureSink)
+	ut.SetSink(sink)
+	defer ut.SetSink(nil)
+
+	var line int
+	ft, _, _ := MetaTester("Helper", func(tt *ut.TestTools) {
+		_, _, line, _ = runtime.Caller(0)
+		assertAllPositive(tt, 1, -1)
+	})
+	line++
+	if !ft.fail {
+		t.Fatalf("Expected the test to fail")
+	}
+	ut.Equals(t, 1, len(sink.failures))
+	ut.Equals(t, line, sink.failures[0].Line)
+	location := fmt.Sprintf("helper_test.go:%d:", line)
+	if output := ft.Output(); !strings.HasPrefix(output, location) {
+		t.Fatalf("Expected the failure to be reported at %s, got:\n%s", location, output)
+	}
+
+	sink.failures = nil
+	ft = new(fakeT)
+	_, _, line, _ = runtime.Caller(0)
+	assertShort(ft, "too long")
+	line++
+	if !ft.fail {
+		t.Fatalf("Expected the test to fail")
+	}
+	ut.Equals(t, 1, len(sink.failures))
+	ut.Equals(t, line, sink.failures[0].Line)
+}
+
+// helperT is a fakeT recording the functions that call its Helper method
+type helperT struct {
+	fakeT
+	helpers map[string]bool
+}
+
+func (t *helperT) Helper() {
+	pc, _, _, _ := runtime.Caller(1)
+	if t.helpers == nil {
+		t.helpers = make(map[string]bool)
+	}
+	t.helpers[runtime.FuncForPC(pc).Name()] = true
+}
+
+func TestHelperCalls(t *testing.T) {
+	ht := new(helperT)
+	ht.name = "HelperCalls"
+	ut.Equals(ht, 1, 2)
+	tt := ut.ToolsBeginTest(ht, false)
+	tt.FinishTest()
+
+	for _, name := range []string{"Equals", "(*internal).notEqualsWith", "(*internal).failf", "(*internal).log"} {
+		if !ht.helpers["github.com/epiclabs-io/ut."+name] {
+			t.Fatalf("Expected %s to call Helper on the test, got calls from %v", name, ht.helpers)
+		}
+	}
+	// FinishTest is deferred, so marking it would attribute its reports to the runtime
+	if ht.helpers["github.com/epiclabs-io/ut.(*TestTools).FinishTest"] {
+		t.Fatalf("Expected FinishTest not to call Helper on the test")
+	}
+}
@@ -20,31 +20,111 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
 	"reflect"
-	"runtime"
-
-	"github.com/epiclabs-io/diff3"
+	"strings"
 )
 
 type internal struct {
+	t       T      // test the output goes to, nil to print it to stdout
+	message string // context added to failure reports, see WithMessage
 }
 
 // Internal defines test functions that can be used to build other test functions
 // It is built as a struct to avoid polluting the namespace
-// These functions just make checks or print messages, they don't stop the test
+// These functions just make checks or print messages, they don't stop the test.
+// Failures are reported at the caller of the function using them, callDepth
+// levels above. Functions marked with Helper are skipped, so helpers
+// calling each other can all pass a callDepth of 0
 var Internal internal
 
+// For returns the same test functions, but logging their output through
+// the given test instead of printing it to stdout, so it is attributed to it
+func (in *internal) For(t T) *internal {
+	return &internal{t: t, message: in.message}
+}
+
+// WithMessage returns the same test functions, but adding the given context to
+// the failures they report, e.g. the iteration of a loop the check was made in.
+// msgAndArgs is an optional format string followed by its arguments
+func (in *internal) WithMessage(msgAndArgs ...interface{}) *internal {
+	message := formatMessage(msgAndArgs)
+	if message == "" {
+		return in
+	}
+	return &internal{t: in.t, message: message}
+}
+
+// formatMessage formats the optional message and arguments assertions take
+// last. If the first one is not a format string, they are printed one after another
+func formatMessage(msgAndArgs []interface{}) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+	msg, ok := msgAndArgs[0].(string)
+	switch {
+	case !ok:
+		return fmt.Sprint(msgAndArgs...)
+	case len(msgAndArgs) == 1:
+		return msg
+	}
+	return fmt.Sprintf(msg, msgAndArgs[1:]...)
+}
+
+// failf reports a failed check made by a test function that was called
+// callDepth levels above its own caller, skipping helpers, see Helper.
+// The report is logged as a single message and handed to the Sink, if any,
+// unless it was made by a block that EventuallyBlock or ConsistentlyBlock retry.
+// The location is only added to the message if there is no test or it cannot
+// skip helpers, since testing.T already prefixes its logs with the caller's
+func (in *internal) failf(callDepth int, format string, args ...interface{}) {
+	helperOf(in.t)()
+	file, line := callerLocation(2 + callDepth)
+	message := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	if in.message != "" {
+		if strings.HasPrefix(message, "\n") {
+			message = in.message + message
+		} else {
+			message = in.message + ": " + message
+		}
+	}
+	f := &Failure{File: file, Line: line, Message: strings.TrimLeft(message, "\n")}
+	if in.t != nil {
+		f.Test = in.t.Name()
+	}
+	if _, inAttempt := in.t.(*attemptT); !inAttempt {
+		notifySink(f)
+	}
+
+	if _, reportsLocation := in.t.(interface{ Helper() }); reportsLocation {
+		in.log(message)
+		return
+	}
+	location := fmt.Sprintf("%s:%d:", relativePath(file), line)
+	if !strings.HasPrefix(message, "\n") {
+		location += " "
+	}
+	in.log(location + message)
+}
+
+// log writes a message through the test, or to stdout if there is none
+func (in *internal) log(message string) {
+	helperOf(in.t)()
+	if in.t == nil {
+		fmt.Printf("%s\n\n", message)
+		return
+	}
+	in.t.Log(message)
+}
+
 func (in *internal) Suspend() {
 	c := make(chan bool)
 	<-c
 }
 
 func (in *internal) NotAssert(callDepth int, condition bool, msg string, v ...interface{}) bool {
+	helperOf(in.t)()
 	if !condition {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		fmt.Printf("%s:%d: Assertion failed: "+msg+"\n\n", append([]interface{}{filepath.Base(file), line}, v...)...)
+		in.failf(callDepth, "Assertion failed: "+msg, v...)
 		return true
 	}
 	return false
@@ -58,18 +138,56 @@ func (in *internal) ErrorString(err error) string {
 }
 
 func (in *internal) NotOk(callDepth int, err error) bool {
+	helperOf(in.t)()
 	if err != nil {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		fmt.Printf("%s:%d: unexpected error: %s\n\n", filepath.Base(file), line, err.Error())
+		in.failf(callDepth, "unexpected error: %s", err.Error())
 		return true
 	}
 	return false
 }
 
+// NotEquals compares two values with reflect.DeepEqual. Differences within
+// structs, maps or slices are listed by their Go path, e.g. .Orders[2].Qty
 func (in *internal) NotEquals(callDepth int, expected, actual interface{}) bool {
-	if !reflect.DeepEqual(expected, actual) {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		fmt.Printf("%s:%d:\n\n\texpected: %#v\n\n\tgot: %#v\n\n", filepath.Base(file), line, expected, actual)
+	helperOf(in.t)()
+	return in.notEqualsWith(callDepth+1, expected, actual, newOptions(options{}, nil))
+}
+
+// notEqualsWith compares two values like NotEquals, relaxing the comparison
+// as per the FloatEpsilon and equality options, such as IgnoreFields
+func (in *internal) notEqualsWith(callDepth int, expected, actual interface{}, o *options) bool {
+	helperOf(in.t)()
+	if reflect.DeepEqual(expected, actual) {
+		return false
+	}
+	differences := compareGo(expected, actual, o)
+	if len(differences) == 0 && (o.json.epsilon > 0 || o.equality.relaxed()) {
+		return false
+	}
+	if len(differences) == 0 || len(differences) == 1 && differences[0].path == "" {
+		in.failf(callDepth, "\n\n\texpected: %#v\n\n\tgot: %#v", expected, actual)
+		return true
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "\n\n%s", formatGoDifferences(differences, verbose()))
+	if verbose() {
+		fmt.Fprintf(&buf, "\n\texpected: %s\n\n\tgot: %s", formatGo(expected), formatGo(actual))
+	}
+	in.failf(callDepth, "%s", buf.String())
+	return true
+}
+
+func (in *internal) NotBytesEquals(callDepth int, expected, actual []byte) bool {
+	helperOf(in.t)()
+	if !bytes.Equal(expected, actual) {
+		var buf strings.Builder
+		offset := firstDifference(expected, actual)
+		fmt.Fprintf(&buf, "\n\n\tbinary data differs at offset %#x (%d)\n", offset, offset)
+		if len(expected) != len(actual) {
+			fmt.Fprintf(&buf, "\tlength mismatch: expected %d bytes, got %d\n", len(expected), len(actual))
+		}
+		fmt.Fprintf(&buf, "\n%s", hexdumpDiff(expected, actual, offset))
+		in.failf(callDepth, "%s", buf.String())
 		return true
 	}
 	return false
@@ -81,50 +199,52 @@ func (in *internal) JSONPretty(jsonBytes []byte) []byte {
 	return buf.Bytes()
 }
 
-func (in *internal) NotJSONEquals(callDepth int, expected, actual []byte) bool {
+// NotJSONEquals compares two JSON documents, which can be tuned with options
+// such as JSONSubset, IgnorePaths, UnorderedArrays or FloatEpsilon.
+// A message given with Msg is added to the report
+func (in *internal) NotJSONEquals(callDepth int, expected, actual []byte, opts ...Option) bool {
+	helperOf(in.t)()
 	//credit for the trick: turtlemonvh https://gist.github.com/turtlemonvh/e4f7404e28387fadb8ad275a99596f67
+	o := newOptions(options{}, opts)
+	in = in.WithMessage(o.message)
 	var o1 interface{}
 	var o2 interface{}
 
 	err := json.Unmarshal(expected, &o1)
 	if err != nil {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		fmt.Printf("%s:%d:\n\n\tJSONEquals: Error decoding 'expected' JSON: %s.\n\t Can't decode this: `%s`\n\n",
-			filepath.Base(file), line, err, string(expected))
+		in.failf(callDepth, "\n\n\tJSONEquals: Error decoding 'expected' JSON: %s.\n\t Can't decode this: `%s`", err, string(expected))
 		return true
 	}
 	err = json.Unmarshal(actual, &o2)
 	if err != nil {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		fmt.Printf("%s:%d:\n\n\tJSONEquals: Error decoding 'actual' JSON: %s.\n\tCan't decode this: `%s`\n\n",
-			filepath.Base(file), line, err, string(actual))
+		in.failf(callDepth, "\n\n\tJSONEquals: Error decoding 'actual' JSON: %s.\n\tCan't decode this: `%s`", err, string(actual))
 		return true
 	}
 
-	if !reflect.DeepEqual(o1, o2) {
-		_, file, line, _ := runtime.Caller(2 + callDepth)
-		expectedPretty := in.JSONPretty(expected)
-		actualPretty := in.JSONPretty(actual)
-		fmt.Printf("%s:%d:\n\n\texpected JSON: %s\n\n\tgot JSON: %s\n\n", filepath.Base(file), line, expectedPretty, actualPretty)
-		r, err := diff3.Merge(bytes.NewReader(expectedPretty), bytes.NewReader([]byte{}), bytes.NewReader(actualPretty), true, "EXPECTED", "ACTUAL")
-		if err == nil && r.Conflicts {
-			diff, err := ioutil.ReadAll(r.Result)
-			if err == nil {
-				fmt.Printf("Diff:\n%s\n", string(diff))
+	differences := compareJSON(o1, o2, o.json)
+	if len(differences) > 0 {
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "\n\n%s", formatJSONDifferences(differences, verbose()))
+		if verbose() {
+			expectedPretty := in.JSONPretty(expected)
+			actualPretty := in.JSONPretty(actual)
+			fmt.Fprintf(&buf, "\n\texpected JSON: %s\n\n\tgot JSON: %s\n", expectedPretty, actualPretty)
+			if diff := textDiff(string(expectedPretty), string(actualPretty)); diff != "" {
+				fmt.Fprintf(&buf, "\nDiff:\n%s", diff)
 			}
 		}
+		in.failf(callDepth, "%s", buf.String())
 		return true
 	}
 	return false
 }
 
 func (in *internal) Fatal(callDepth int, args ...interface{}) {
-	_, file, line, _ := runtime.Caller(2 + callDepth)
-	fmt.Println(append([]interface{}{fmt.Sprintf("%s:%d: FATAL:", filepath.Base(file), line)}, args...)...)
+	helperOf(in.t)()
+	in.failf(callDepth, "FATAL: %s", strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
 }
 
 func (in *internal) Fatalf(callDepth int, formatString string, args ...interface{}) {
-	_, file, line, _ := runtime.Caller(2 + callDepth)
-	fmt.Printf("%s: FATAL: "+formatString+"\n",
-		append([]interface{}{fmt.Sprintf("%s:%d", filepath.Base(file), line)}, args...)...)
+	helperOf(in.t)()
+	in.failf(callDepth, "FATAL: "+formatString, args...)
 }
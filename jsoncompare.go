@@ -0,0 +1,286 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxJSONDifferences caps the number of JSON differences reported,
+	// unless verbose output is enabled
+	maxJSONDifferences = 20
+
+	// maxJSONValueLength caps the length of the values shown in differences
+	maxJSONValueLength = 60
+)
+
+// jsonOptions tune how JSON documents are compared
+type jsonOptions struct {
+	subset    bool
+	ignore    [][]string
+	unordered [][]string
+	epsilon   float64
+}
+
+// JSONSubset makes JSON comparisons only check that the actual document contains
+// the expected one: extra object members in the actual document are allowed,
+// and so are extra elements in arrays compared with UnorderedArrays
+func JSONSubset() Option {
+	return func(o *options) {
+		o.json.subset = true
+	}
+}
+
+// IgnorePaths makes JSON comparisons skip the values at the given JSON Pointers.
+// A * token matches any member or element, e.g. /items/*/id
+func IgnorePaths(pointers ...string) Option {
+	return func(o *options) {
+		o.json.ignore = append(o.json.ignore, mustParseJSONPointers(pointers)...)
+	}
+}
+
+// UnorderedArrays makes JSON comparisons treat the arrays at the given JSON
+// Pointers as multisets, regardless of the order of their elements.
+// Use "" for the root document and * tokens to match any member or element
+func UnorderedArrays(pointers ...string) Option {
+	return func(o *options) {
+		o.json.unordered = append(o.json.unordered, mustParseJSONPointers(pointers)...)
+	}
+}
+
+// FloatEpsilon makes JSON comparisons consider numbers equal if they
+// differ by at most epsilon. EqualsKey and EqualsFile apply it to the
+// floats they compare, wherever they are in the value
+func FloatEpsilon(epsilon float64) Option {
+	return func(o *options) {
+		o.json.epsilon = epsilon
+	}
+}
+
+func mustParseJSONPointers(pointers []string) [][]string {
+	parsed := make([][]string, len(pointers))
+	for i, pointer := range pointers {
+		tokens, err := parseJSONPointer(pointer)
+		if err != nil {
+			panic(err)
+		}
+		parsed[i] = tokens
+	}
+	return parsed
+}
+
+// jsonDifference describes a difference found at a path of two JSON documents
+type jsonDifference struct {
+	path     string
+	expected interface{}
+	actual   interface{}
+	missing  bool // the value is missing in the actual document
+	extra    bool // the value is only present in the actual document
+}
+
+// jsonComparison compares two decoded JSON documents
+type jsonComparison struct {
+	jsonOptions
+	differences []jsonDifference
+}
+
+func matchesAnyPointer(patterns [][]string, tokens []string) bool {
+	for _, pattern := range patterns {
+		if matchJSONPointer(pattern, tokens) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareJSON returns the differences between two decoded JSON documents
+func compareJSON(expected, actual interface{}, o jsonOptions) []jsonDifference {
+	c := &jsonComparison{jsonOptions: o}
+	c.compare(nil, expected, actual)
+	sort.SliceStable(c.differences, func(i, j int) bool {
+		return lessJSONPointer(c.differences[i].path, c.differences[j].path)
+	})
+	return c.differences
+}
+
+// lessJSONPointer orders JSON Pointers token by token, comparing array indexes numerically
+func lessJSONPointer(a, b string) bool {
+	ta, _ := parseJSONPointer(a)
+	tb, _ := parseJSONPointer(b)
+	for i := 0; i < len(ta) && i < len(tb); i++ {
+		if ta[i] == tb[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		if errA == nil && errB == nil {
+			return na < nb
+		}
+		return ta[i] < tb[i]
+	}
+	return len(ta) < len(tb)
+}
+
+func (c *jsonComparison) add(d jsonDifference) {
+	c.differences = append(c.differences, d)
+}
+
+func (c *jsonComparison) compare(tokens []string, expected, actual interface{}) {
+	if matchesAnyPointer(c.ignore, tokens) {
+		return
+	}
+	path := formatJSONPointer(tokens)
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			c.add(jsonDifference{path: path, expected: expected, actual: actual})
+			return
+		}
+		for key, value := range e {
+			memberTokens := appendToken(tokens, key)
+			actualValue, ok := a[key]
+			if !ok {
+				if !matchesAnyPointer(c.ignore, memberTokens) {
+					c.add(jsonDifference{path: formatJSONPointer(memberTokens), expected: value, missing: true})
+				}
+				continue
+			}
+			c.compare(memberTokens, value, actualValue)
+		}
+		if c.subset {
+			return
+		}
+		for key, value := range a {
+			memberTokens := appendToken(tokens, key)
+			if _, ok := e[key]; !ok && !matchesAnyPointer(c.ignore, memberTokens) {
+				c.add(jsonDifference{path: formatJSONPointer(memberTokens), actual: value, extra: true})
+			}
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			c.add(jsonDifference{path: path, expected: expected, actual: actual})
+			return
+		}
+		if matchesAnyPointer(c.unordered, tokens) {
+			c.compareUnordered(tokens, e, a)
+			return
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			elementTokens := appendToken(tokens, strconv.Itoa(i))
+			switch {
+			case i >= len(a):
+				c.add(jsonDifference{path: formatJSONPointer(elementTokens), expected: e[i], missing: true})
+			case i >= len(e):
+				c.add(jsonDifference{path: formatJSONPointer(elementTokens), actual: a[i], extra: true})
+			default:
+				c.compare(elementTokens, e[i], a[i])
+			}
+		}
+	case float64:
+		a, ok := actual.(float64)
+		if !ok || math.Abs(e-a) > c.epsilon || math.IsNaN(a-e) {
+			c.add(jsonDifference{path: path, expected: expected, actual: actual})
+		}
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			c.add(jsonDifference{path: path, expected: expected, actual: actual})
+		}
+	}
+}
+
+// compareUnordered pairs every expected element with an equal actual element
+func (c *jsonComparison) compareUnordered(tokens []string, expected, actual []interface{}) {
+	matched := make([]bool, len(actual))
+	for i, e := range expected {
+		found := false
+		for j, a := range actual {
+			if matched[j] {
+				continue
+			}
+			probe := &jsonComparison{jsonOptions: c.jsonOptions}
+			probe.compare(appendToken(tokens, strconv.Itoa(j)), e, a)
+			if len(probe.differences) == 0 {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.add(jsonDifference{path: formatJSONPointer(appendToken(tokens, strconv.Itoa(i))), expected: e, missing: true})
+		}
+	}
+	if c.subset {
+		return
+	}
+	for j, a := range actual {
+		if !matched[j] {
+			c.add(jsonDifference{path: formatJSONPointer(appendToken(tokens, strconv.Itoa(j))), actual: a, extra: true})
+		}
+	}
+}
+
+// String describes the difference, e.g. "/items/3/price: expected 9.99, got 10.5"
+func (d *jsonDifference) String() string {
+	path := d.path
+	if path == "" {
+		path = "(root)"
+	}
+	switch {
+	case d.missing:
+		return fmt.Sprintf("%s: missing in actual, expected %s", path, jsonValueString(d.expected))
+	case d.extra:
+		return fmt.Sprintf("%s: not expected, got %s", path, jsonValueString(d.actual))
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", path, jsonValueString(d.expected), jsonValueString(d.actual))
+}
+
+// jsonValueString renders a decoded JSON value compactly
+func jsonValueString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > maxJSONValueLength {
+		return string(b[:maxJSONValueLength]) + "..."
+	}
+	return string(b)
+}
+
+// formatJSONDifferences lists the differences one per line, capping their
+// number unless all of them were requested
+func formatJSONDifferences(differences []jsonDifference, all bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\tJSON documents differ in %s:\n", places(len(differences)))
+	for i := range differences {
+		if i == maxJSONDifferences && !all {
+			fmt.Fprintf(&b, "\t... and %d more. Set UT_VERBOSE to see them all along with the full documents\n",
+				len(differences)-maxJSONDifferences)
+			break
+		}
+		fmt.Fprintf(&b, "\t%s\n", differences[i].String())
+	}
+	return b.String()
+}
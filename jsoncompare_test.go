@@ -0,0 +1,85 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestJSONEqualsOptions(t *testing.T) {
+	var tests = []struct {
+		expected string
+		actual   string
+		opts     []interface{}
+		fail     bool
+	}{
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, nil, true},
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, []interface{}{ut.JSONSubset()}, false},
+		{`{"a": {"x": 1}}`, `{"a": {"x": 1, "y": 2}, "b": 2}`, []interface{}{ut.JSONSubset()}, false},
+		{`{"a": 1, "c": 3}`, `{"a": 1, "b": 2}`, []interface{}{ut.JSONSubset()}, true},
+		{`{"id": 1, "items": [{"id": 7, "n": 1}]}`, `{"id": 2, "items": [{"id": 8, "n": 1}]}`,
+			[]interface{}{ut.IgnorePaths("/id", "/items/*/id")}, false},
+		{`{"id": 1, "n": 1}`, `{"n": 1}`, []interface{}{ut.IgnorePaths("/id")}, false},
+		{`{"tags": ["a", "b", "b"]}`, `{"tags": ["b", "a", "b"]}`, nil, true},
+		{`{"tags": ["a", "b", "b"]}`, `{"tags": ["b", "a", "b"]}`, []interface{}{ut.UnorderedArrays("/tags")}, false},
+		{`{"tags": ["a", "b", "b"]}`, `{"tags": ["b", "a", "a"]}`, []interface{}{ut.UnorderedArrays("/tags")}, true},
+		{`[{"k": [1, 2]}, {"k": [3]}]`, `[{"k": [3]}, {"k": [2, 1]}]`, []interface{}{ut.UnorderedArrays("", "/*/k")}, false},
+		{`["a"]`, `["b", "a"]`, []interface{}{ut.UnorderedArrays(""), ut.JSONSubset()}, false},
+		{`{"price": 9.99}`, `{"price": 9.9900001}`, nil, true},
+		{`{"price": 9.99}`, `{"price": 9.9900001}`, []interface{}{ut.FloatEpsilon(1e-6)}, false},
+		{`{"price": 9.99}`, `{"price": 10.5}`, []interface{}{ut.FloatEpsilon(1e-6)}, true},
+	}
+
+	for i, test := range tests {
+		ft := new(fakeT)
+		ut.JSONEquals(ft, []byte(test.expected), []byte(test.actual), test.opts...)
+		if ft.fail != test.fail {
+			t.Fatalf("#%d: expected failed=%v, got %v", i, test.fail, ft.fail)
+		}
+	}
+
+	ft := new(fakeT)
+	ut.JSONContains(ft, []byte(`{"a": 1}`), []byte(`{"b": 2, "a": 1}`))
+	if ft.fail {
+		t.Fatalf("Expected JSONContains to accept extra members")
+	}
+}
+
+func TestJSONDifferencesOutput(t *testing.T) {
+	expected := `{"items": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, {"price": 9.99}], "meta": {"etag": "x"}}`
+	actual := `{"items": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, {"price": 10.5}], "meta": {}, "extra": true}`
+
+	ft := new(fakeT)
+	ut.JSONEquals(ft, []byte(expected), []byte(actual))
+	output := ft.Output()
+	for _, line := range []string{
+		"\tJSON documents differ in 3 places:\n",
+		"\t/extra: not expected, got true\n",
+		"\t/items/11/price: expected 9.99, got 10.5\n",
+		"\t/meta/etag: missing in actual, expected \"x\"\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "got JSON") {
+		t.Fatalf("Expected full documents not to be printed unless requested")
+	}
+}
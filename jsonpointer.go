@@ -0,0 +1,124 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer such as /items/0/name
+// into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// resolveJSONPointer returns the value the pointer refers to in a decoded JSON document
+func resolveJSONPointer(root interface{}, pointer string) (interface{}, bool) {
+	tokens, err := parseJSONPointer(pointer)
+	if err != nil {
+		return nil, false
+	}
+	value := root
+	for _, token := range tokens {
+		var ok bool
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if value, ok = v[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// formatJSONPointer builds a JSON Pointer out of reference tokens
+func formatJSONPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1))
+	}
+	return b.String()
+}
+
+// appendToken returns a copy of tokens with token appended,
+// so that sibling paths never share their backing array
+func appendToken(tokens []string, token string) []string {
+	path := make([]string, len(tokens), len(tokens)+1)
+	copy(path, tokens)
+	return append(path, token)
+}
+
+// matchJSONPointer tells whether the path described by tokens matches pattern,
+// in which a * token matches any object member or array index
+func matchJSONPointer(pattern, tokens []string) bool {
+	if len(pattern) != len(tokens) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// walkJSON calls f for every value in a decoded JSON document, depth first and
+// visiting object members in sorted order. The value is replaced by what f returns
+func walkJSON(doc interface{}, tokens []string, f func(tokens []string, value interface{}) interface{}) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedMapKeys(v) {
+			v[key] = walkJSON(v[key], appendToken(tokens, key), f)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = walkJSON(v[i], appendToken(tokens, strconv.Itoa(i)), f)
+		}
+	}
+	return f(tokens, doc)
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,430 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Matcher is a reusable check on a value that can describe itself, so that
+// AssertThat reports every failure the same way:
+//
+//	expected: a string matching /^id-/
+//	     but: was "user-1"
+type Matcher interface {
+	// Match tells whether actual satisfies the matcher
+	Match(actual interface{}) bool
+	// Describe describes the values that match, e.g. "has length 3"
+	Describe() string
+	// DescribeMismatch explains why actual does not match, e.g. "had length 2"
+	DescribeMismatch(actual interface{}) string
+}
+
+// AssertThat checks that actual satisfies matcher. Otherwise it fails the test,
+// reporting what was expected and why actual does not match
+func (tt *TestTools) AssertThat(actual interface{}, matcher Matcher, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotMatches(0, actual, matcher) {
+		tt.Error(fmt.Errorf("Expected %s", matcher.Describe()))
+	}
+}
+
+// mismatchFinder is implemented by matchers that describe the mismatch while
+// matching, so that actual is only read once
+type mismatchFinder interface {
+	findMismatch(actual interface{}) (mismatch string, found bool)
+}
+
+// NotMatches checks whether actual satisfies matcher
+func (in *internal) NotMatches(callDepth int, actual interface{}, matcher Matcher) bool {
+	helperOf(in.t)()
+	var mismatch string
+	if finder, ok := matcher.(mismatchFinder); ok {
+		var found bool
+		if mismatch, found = finder.findMismatch(actual); !found {
+			return false
+		}
+	} else if matcher.Match(actual) {
+		return false
+	} else {
+		mismatch = matcher.DescribeMismatch(actual)
+	}
+	in.failf(callDepth, "\n\n\texpected: %s\n\n\tbut: %s", matcher.Describe(), mismatch)
+	return true
+}
+
+// describeValue formats a value in a single line, for descriptions
+func describeValue(v interface{}) string {
+	return formatGoValue(addressable(reflect.ValueOf(v)))
+}
+
+// was is the default mismatch description, showing the actual value
+func was(actual interface{}) string {
+	return "was " + describeValue(actual)
+}
+
+type equalToMatcher struct {
+	expected interface{}
+}
+
+// EqualTo matches values deeply equal to expected. Numbers of different
+// kinds match if they have the same value, e.g. 3 and float64(3)
+func EqualTo(expected interface{}) Matcher {
+	return &equalToMatcher{expected}
+}
+
+func (m *equalToMatcher) Match(actual interface{}) bool {
+	if reflect.DeepEqual(m.expected, actual) {
+		return true
+	}
+	if _, _, err := toFloats(m.expected, actual); err == nil {
+		c, err := compareOrdered(m.expected, actual)
+		return err == nil && c == 0
+	}
+	return false
+}
+
+func (m *equalToMatcher) Describe() string {
+	return "equal to " + describeValue(m.expected)
+}
+
+func (m *equalToMatcher) DescribeMismatch(actual interface{}) string {
+	differences := compareGo(m.expected, actual, newOptions(options{}, nil))
+	if len(differences) == 0 || len(differences) == 1 && differences[0].path == "" {
+		return was(actual)
+	}
+	descriptions := make([]string, len(differences))
+	for i := range differences {
+		descriptions[i] = differences[i].String()
+	}
+	return "differed at " + strings.Join(descriptions, ", ")
+}
+
+type allOfMatcher struct {
+	matchers []Matcher
+}
+
+// AllOf matches values that satisfy all of the matchers
+func AllOf(matchers ...Matcher) Matcher {
+	return &allOfMatcher{matchers}
+}
+
+func (m *allOfMatcher) Match(actual interface{}) bool {
+	for _, matcher := range m.matchers {
+		if !matcher.Match(actual) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *allOfMatcher) Describe() string {
+	return describeAll(m.matchers, " and ")
+}
+
+// DescribeMismatch explains the first matcher actual does not satisfy
+func (m *allOfMatcher) DescribeMismatch(actual interface{}) string {
+	for _, matcher := range m.matchers {
+		if !matcher.Match(actual) {
+			return matcher.DescribeMismatch(actual)
+		}
+	}
+	return was(actual)
+}
+
+type anyOfMatcher struct {
+	matchers []Matcher
+}
+
+// AnyOf matches values that satisfy at least one of the matchers
+func AnyOf(matchers ...Matcher) Matcher {
+	return &anyOfMatcher{matchers}
+}
+
+func (m *anyOfMatcher) Match(actual interface{}) bool {
+	for _, matcher := range m.matchers {
+		if matcher.Match(actual) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *anyOfMatcher) Describe() string {
+	return describeAll(m.matchers, " or ")
+}
+
+func (m *anyOfMatcher) DescribeMismatch(actual interface{}) string {
+	return was(actual)
+}
+
+// describeAll joins the descriptions of matchers in parentheses
+func describeAll(matchers []Matcher, separator string) string {
+	descriptions := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		descriptions[i] = matcher.Describe()
+	}
+	return "(" + strings.Join(descriptions, separator) + ")"
+}
+
+type notMatcher struct {
+	matcher Matcher
+}
+
+// Not matches values that don't satisfy matcher
+func Not(matcher Matcher) Matcher {
+	return &notMatcher{matcher}
+}
+
+func (m *notMatcher) Match(actual interface{}) bool {
+	return !m.matcher.Match(actual)
+}
+
+func (m *notMatcher) Describe() string {
+	return "not " + m.matcher.Describe()
+}
+
+func (m *notMatcher) DescribeMismatch(actual interface{}) string {
+	return was(actual)
+}
+
+type hasFieldMatcher struct {
+	name    string
+	matcher Matcher
+}
+
+// HasField matches structs, or pointers to structs, whose field with the given
+// name satisfies matcher. Nested fields can be given as a path, e.g. "Address.City"
+func HasField(name string, matcher Matcher) Matcher {
+	return &hasFieldMatcher{name, matcher}
+}
+
+// field returns the value of the field, or an error explaining why there is none
+func (m *hasFieldMatcher) field(actual interface{}) (interface{}, error) {
+	v := addressable(reflect.ValueOf(actual))
+	for _, name := range strings.Split(m.name, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, fmt.Errorf("was nil instead of a struct with field %s", m.name)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("was not a struct: %s", describeValue(actual))
+		}
+		if v = v.FieldByName(name); !v.IsValid() {
+			return nil, fmt.Errorf("had no field %s", m.name)
+		}
+	}
+	value, ok := interfaceValue(v)
+	if !ok {
+		return nil, fmt.Errorf("had field %s, but it cannot be read", m.name)
+	}
+	return value.Interface(), nil
+}
+
+func (m *hasFieldMatcher) Match(actual interface{}) bool {
+	value, err := m.field(actual)
+	return err == nil && m.matcher.Match(value)
+}
+
+func (m *hasFieldMatcher) Describe() string {
+	return "has field " + m.name + " " + m.matcher.Describe()
+}
+
+func (m *hasFieldMatcher) DescribeMismatch(actual interface{}) string {
+	value, err := m.field(actual)
+	if err != nil {
+		return err.Error()
+	}
+	return "field " + m.name + " " + m.matcher.DescribeMismatch(value)
+}
+
+type eachMatcher struct {
+	matcher Matcher
+}
+
+// Each matches collections whose elements all satisfy matcher. Collections are
+// the same Contains accepts: slices, arrays, map values, strings and channels
+func Each(matcher Matcher) Matcher {
+	return &eachMatcher{matcher: matcher}
+}
+
+// findMismatch describes the first element of actual not satisfying the
+// matcher, or why its elements cannot be checked
+func (m *eachMatcher) findMismatch(actual interface{}) (string, bool) {
+	values, err := elements(actual)
+	if err != nil {
+		return err.Error(), true
+	}
+	for i, v := range values {
+		element, ok := interfaceValue(v)
+		if !ok {
+			return "elements cannot be read", true
+		}
+		if !m.matcher.Match(element.Interface()) {
+			return fmt.Sprintf("element [%d] %s", i, m.matcher.DescribeMismatch(element.Interface())), true
+		}
+	}
+	return "", false
+}
+
+func (m *eachMatcher) Match(actual interface{}) bool {
+	_, found := m.findMismatch(actual)
+	return !found
+}
+
+func (m *eachMatcher) Describe() string {
+	return "every element " + m.matcher.Describe()
+}
+
+func (m *eachMatcher) DescribeMismatch(actual interface{}) string {
+	if mismatch, found := m.findMismatch(actual); found {
+		return mismatch
+	}
+	return was(actual)
+}
+
+type hasLenMatcher struct {
+	length int
+}
+
+// HasLen matches collections and strings with the given length
+func HasLen(length int) Matcher {
+	return &hasLenMatcher{length}
+}
+
+func (m *hasLenMatcher) Match(actual interface{}) bool {
+	v, err := collection(actual)
+	return err == nil && v.Len() == m.length
+}
+
+func (m *hasLenMatcher) Describe() string {
+	return fmt.Sprintf("has length %d", m.length)
+}
+
+func (m *hasLenMatcher) DescribeMismatch(actual interface{}) string {
+	v, err := collection(actual)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("had length %d: %s", v.Len(), describeValue(actual))
+}
+
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+// MatchesRegex matches strings, byte slices and fmt.Stringers containing a match
+// of the regular expression expr. It panics if expr is not a valid one
+func MatchesRegex(expr string) Matcher {
+	return &regexMatcher{regexp.MustCompile(expr)}
+}
+
+func (m *regexMatcher) Match(actual interface{}) bool {
+	s, ok := matcherText(actual)
+	return ok && m.re.MatchString(s)
+}
+
+func (m *regexMatcher) Describe() string {
+	return "a string matching /" + m.re.String() + "/"
+}
+
+func (m *regexMatcher) DescribeMismatch(actual interface{}) string {
+	if _, ok := matcherText(actual); !ok {
+		return fmt.Sprintf("was not a string but a %T", actual)
+	}
+	return was(actual)
+}
+
+// matcherText returns the text of strings, byte slices and fmt.Stringers
+func matcherText(actual interface{}) (string, bool) {
+	switch v := actual.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	return "", false
+}
+
+type jsonPathMatcher struct {
+	pointer string
+	matcher Matcher
+}
+
+// JSONPath matches JSON documents whose value at the given JSON Pointer, such as
+// /items/0/name, satisfies matcher. Documents can be given as []byte,
+// json.RawMessage or string, or else any value is marshalled to JSON first.
+// Values are decoded as encoding/json does, so numbers are float64;
+// EqualTo matches them with numbers of any kind
+func JSONPath(pointer string, matcher Matcher) Matcher {
+	if _, err := parseJSONPointer(pointer); err != nil {
+		panic(err)
+	}
+	return &jsonPathMatcher{pointer, matcher}
+}
+
+// value returns the value the pointer refers to, or an error explaining why there is none
+func (m *jsonPathMatcher) value(actual interface{}) (interface{}, error) {
+	var data []byte
+	switch v := actual.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(actual); err != nil {
+			return nil, fmt.Errorf("could not be marshalled to JSON: %s", err)
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("was not valid JSON: %s", err)
+	}
+	value, ok := resolveJSONPointer(doc, m.pointer)
+	if !ok {
+		return nil, fmt.Errorf("had nothing at %s", m.pointer)
+	}
+	return value, nil
+}
+
+func (m *jsonPathMatcher) Match(actual interface{}) bool {
+	value, err := m.value(actual)
+	return err == nil && m.matcher.Match(value)
+}
+
+func (m *jsonPathMatcher) Describe() string {
+	return "JSON with " + m.pointer + " " + m.matcher.Describe()
+}
+
+func (m *jsonPathMatcher) DescribeMismatch(actual interface{}) string {
+	value, err := m.value(actual)
+	if err != nil {
+		return err.Error()
+	}
+	return m.pointer + " " + m.matcher.DescribeMismatch(value)
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type address struct {
+	City string
+}
+
+type customer struct {
+	Name    string
+	Email   string
+	Tags    []string
+	Address *address
+}
+
+func TestMatchers(t *testing.T) {
+	c := customer{Name: "Ann", Email: "ann@example.com", Tags: []string{"vip", "new"}, Address: &address{"Paris"}}
+
+	var tests = []struct {
+		name    string
+		actual  interface{}
+		matcher ut.Matcher
+		fail    bool
+	}{
+		{"equal to", 3, ut.EqualTo(3), false},
+		{"equal to other number kind", float64(3), ut.EqualTo(3), false},
+		{"not equal to", "a", ut.EqualTo("b"), true},
+		{"all of", "abc", ut.AllOf(ut.HasLen(3), ut.MatchesRegex("^a")), false},
+		{"all of failing", "abc", ut.AllOf(ut.HasLen(3), ut.MatchesRegex("^b")), true},
+		{"any of", "abc", ut.AnyOf(ut.HasLen(2), ut.MatchesRegex("^a")), false},
+		{"any of failing", "abc", ut.AnyOf(ut.HasLen(2), ut.MatchesRegex("^b")), true},
+		{"not", 3, ut.Not(ut.EqualTo(4)), false},
+		{"has field", c, ut.HasField("Name", ut.EqualTo("Ann")), false},
+		{"has nested field through pointer", &c, ut.HasField("Address.City", ut.EqualTo("Paris")), false},
+		{"has missing field", c, ut.HasField("Phone", ut.EqualTo("")), true},
+		{"has field of a non struct", 3, ut.HasField("Name", ut.EqualTo("")), true},
+		{"each", c.Tags, ut.Each(ut.MatchesRegex("^[a-z]+$")), false},
+		{"each failing", []int{1, 2, 3}, ut.Each(ut.Not(ut.EqualTo(2))), true},
+		{"has len", map[string]int{"a": 1}, ut.HasLen(1), false},
+		{"matches regex bytes", []byte("id-12"), ut.MatchesRegex(`^id-\d+$`), false},
+		{"matches regex not a string", 12, ut.MatchesRegex(`12`), true},
+		{"json path", `{"items": [{"qty": 2}]}`, ut.JSONPath("/items/0/qty", ut.EqualTo(2)), false},
+		{"json path marshalled", c, ut.JSONPath("/Address/City", ut.EqualTo("Paris")), false},
+		{"json path missing", []byte(`{"items": []}`), ut.JSONPath("/items/0/qty", ut.EqualTo(2)), true},
+	}
+
+	for _, test := range tests {
+		ft := new(fakeT)
+		ut.AssertThat(ft, test.actual, test.matcher)
+		if ft.fail != test.fail {
+			t.Fatalf("%s: expected failure to be %v, got %v:\n%s", test.name, test.fail, ft.fail, ft.Output())
+		}
+	}
+}
+
+func TestMatcherOutput(t *testing.T) {
+	c := customer{Name: "Ann", Tags: []string{"vip", "New"}}
+
+	var tests = []struct {
+		matcher  ut.Matcher
+		expected string
+	}{
+		{ut.HasField("Tags", ut.Each(ut.MatchesRegex("^[a-z]+$"))),
+			"expected: has field Tags every element a string matching /^[a-z]+$/\n\n\tbut: field Tags element [1] was \"New\""},
+		{ut.AllOf(ut.HasField("Name", ut.EqualTo("Ann")), ut.HasField("Tags", ut.HasLen(1))),
+			"expected: (has field Name equal to \"Ann\" and has field Tags has length 1)\n\n\tbut: field Tags had length 2"},
+		{ut.JSONPath("/Name", ut.Not(ut.EqualTo("Ann"))),
+			"expected: JSON with /Name not equal to \"Ann\"\n\n\tbut: /Name was \"Ann\""},
+		{ut.HasField("Address.City", ut.EqualTo("Paris")),
+			"but: was nil instead of a struct with field Address.City"},
+	}
+	for _, test := range tests {
+		ft, early, _ := MetaTester("MatcherOutput", func(tt *ut.TestTools) {
+			tt.AssertThat(c, test.matcher, "customer %s", c.Name)
+		})
+		if !early || !ft.fail {
+			t.Fatalf("Expected %s to fail the test", test.matcher.Describe())
+		}
+		if output := ft.Output(); !strings.Contains(output, test.expected) || !strings.Contains(output, "customer Ann\n") {
+			t.Fatalf("Expected the failure to contain %q, got:\n%s", test.expected, output)
+		}
+	}
+}
+
+func TestEachOnChannel(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 5
+	ch <- 2
+
+	ft := new(fakeT)
+	ut.AssertThat(ft, ch, ut.Each(ut.Not(ut.EqualTo(5))))
+	if output := ft.Output(); !ft.fail || !strings.Contains(output, "but: element [1] was 5") {
+		t.Fatalf("Expected the mismatching channel element to be reported, got:\n%s", output)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// InDelta checks that two numbers differ by at most delta. Numbers can be of
+// any numeric kind, including time.Duration, and of different kinds
+func (tt *TestTools) InDelta(expected, actual interface{}, delta float64, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotInDelta(0, expected, actual, delta) {
+		tt.Error(errors.New("Numbers differ too much"))
+	}
+}
+
+// InEpsilon checks that the relative error between two numbers,
+// |expected - actual| / |expected|, is at most epsilon
+func (tt *TestTools) InEpsilon(expected, actual interface{}, epsilon float64, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotInEpsilon(0, expected, actual, epsilon) {
+		tt.Error(errors.New("Numbers differ too much"))
+	}
+}
+
+// Greater checks that a > b. Values can be numbers of any kind, time.Duration or time.Time
+func (tt *TestTools) Greater(a, b interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotGreater(0, a, b) {
+		tt.Error(errors.New("Not greater"))
+	}
+}
+
+// Less checks that a < b, see Greater
+func (tt *TestTools) Less(a, b interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotLess(0, a, b) {
+		tt.Error(errors.New("Not less"))
+	}
+}
+
+// Between checks that min <= value <= max, see Greater
+func (tt *TestTools) Between(value, min, max interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotBetween(0, value, min, max) {
+		tt.Error(errors.New("Out of range"))
+	}
+}
+
+// NotInDelta checks whether two numbers differ by at most delta
+func (in *internal) NotInDelta(callDepth int, expected, actual interface{}, delta float64) bool {
+	helperOf(in.t)()
+	e, a, err := toFloats(expected, actual)
+	if err != nil {
+		in.failf(callDepth, "InDelta: %s", err)
+		return true
+	}
+	if difference := math.Abs(e - a); !(difference <= delta) {
+		in.failf(callDepth, "\n\n\texpected: %v\n\n\tgot: %v\n\n\tdifference %g exceeds delta %g", expected, actual, difference, delta)
+		return true
+	}
+	return false
+}
+
+// NotInEpsilon checks whether the relative error between two numbers is at most epsilon
+func (in *internal) NotInEpsilon(callDepth int, expected, actual interface{}, epsilon float64) bool {
+	helperOf(in.t)()
+	e, a, err := toFloats(expected, actual)
+	if err != nil {
+		in.failf(callDepth, "InEpsilon: %s", err)
+		return true
+	}
+	if e == 0 {
+		if a != 0 {
+			in.failf(callDepth, "\n\n\texpected: %v\n\n\tgot: %v\n\n\tthe relative error to 0 is undefined", expected, actual)
+			return true
+		}
+		return false
+	}
+	if relative := math.Abs(e-a) / math.Abs(e); !(relative <= epsilon) {
+		in.failf(callDepth, "\n\n\texpected: %v\n\n\tgot: %v\n\n\trelative error %g exceeds epsilon %g", expected, actual, relative, epsilon)
+		return true
+	}
+	return false
+}
+
+// NotGreater checks whether a > b
+func (in *internal) NotGreater(callDepth int, a, b interface{}) bool {
+	helperOf(in.t)()
+	c, err := compareOrdered(a, b)
+	if err != nil {
+		in.failf(callDepth, "Greater: %s", err)
+		return true
+	}
+	if c <= 0 {
+		in.failf(callDepth, "\n\n\texpected %v to be greater than %v", a, b)
+		return true
+	}
+	return false
+}
+
+// NotLess checks whether a < b
+func (in *internal) NotLess(callDepth int, a, b interface{}) bool {
+	helperOf(in.t)()
+	c, err := compareOrdered(a, b)
+	if err != nil {
+		in.failf(callDepth, "Less: %s", err)
+		return true
+	}
+	if c >= 0 {
+		in.failf(callDepth, "\n\n\texpected %v to be less than %v", a, b)
+		return true
+	}
+	return false
+}
+
+// NotBetween checks whether min <= value <= max
+func (in *internal) NotBetween(callDepth int, value, min, max interface{}) bool {
+	helperOf(in.t)()
+	low, err := compareOrdered(value, min)
+	if err != nil {
+		in.failf(callDepth, "Between: %s", err)
+		return true
+	}
+	high, err := compareOrdered(value, max)
+	if err != nil {
+		in.failf(callDepth, "Between: %s", err)
+		return true
+	}
+	if low < 0 || high > 0 {
+		in.failf(callDepth, "\n\n\texpected %v to be between %v and %v", value, min, max)
+		return true
+	}
+	return false
+}
+
+// toFloats converts two numbers of any numeric kind to float64
+func toFloats(a, b interface{}) (float64, float64, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := toFloat(b)
+	return x, y, err
+}
+
+func toFloat(n interface{}) (float64, error) {
+	v := reflect.ValueOf(n)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", n)
+}
+
+// compareOrdered returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// Integers are compared exactly, even if one is signed and the other is not
+func compareOrdered(a, b interface{}) (int, error) {
+	if t, ok := a.(time.Time); ok {
+		u, ok := b.(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare a time.Time with %T", b)
+		}
+		switch {
+		case t.Before(u):
+			return -1, nil
+		case t.After(u):
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+	xKind, yKind := numberKind(x), numberKind(y)
+	switch {
+	case xKind == reflect.Invalid:
+		return 0, fmt.Errorf("cannot order values of type %T", a)
+	case yKind == reflect.Invalid:
+		return 0, fmt.Errorf("cannot order values of type %T", b)
+	case xKind == reflect.Int && yKind == reflect.Int:
+		return compareInts(x.Int(), y.Int()), nil
+	case xKind == reflect.Uint && yKind == reflect.Uint:
+		return compareUints(x.Uint(), y.Uint()), nil
+	case xKind == reflect.Int && yKind == reflect.Uint:
+		if x.Int() < 0 {
+			return -1, nil
+		}
+		return compareUints(uint64(x.Int()), y.Uint()), nil
+	case xKind == reflect.Uint && yKind == reflect.Int:
+		if y.Int() < 0 {
+			return 1, nil
+		}
+		return compareUints(x.Uint(), uint64(y.Int())), nil
+	}
+	f, g, _ := toFloats(a, b)
+	if math.IsNaN(f) || math.IsNaN(g) {
+		return 0, errors.New("NaN cannot be ordered")
+	}
+	switch {
+	case f < g:
+		return -1, nil
+	case f > g:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// numberKind classifies numbers as reflect.Int, reflect.Uint or reflect.Float64,
+// returning reflect.Invalid for any other value
+func numberKind(v reflect.Value) reflect.Kind {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
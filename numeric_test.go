@@ -0,0 +1,106 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestNumeric(t *testing.T) {
+	now := time.Now()
+	var tests = []struct {
+		name  string
+		check func(tb ut.T)
+		fail  bool
+	}{
+		{"in delta", func(tb ut.T) { ut.InDelta(tb, 3.3333, 10.0/3, 0.001) }, false},
+		{"in delta mixed kinds", func(tb ut.T) { ut.InDelta(tb, 10, uint8(11), 1) }, false},
+		{"in delta too far", func(tb ut.T) { ut.InDelta(tb, 1.0, 1.1, 0.01) }, true},
+		{"in delta NaN", func(tb ut.T) { ut.InDelta(tb, 1.0, math.NaN(), 0.01) }, true},
+		{"in delta not a number", func(tb ut.T) { ut.InDelta(tb, "1", 1, 0.01) }, true},
+		{"in delta durations", func(tb ut.T) { ut.InDelta(tb, time.Second, 990*time.Millisecond, float64(20*time.Millisecond)) }, false},
+		{"in epsilon", func(tb ut.T) { ut.InEpsilon(tb, 1000, 1010, 0.01) }, false},
+		{"in epsilon too far", func(tb ut.T) { ut.InEpsilon(tb, 1000, 1020, 0.01) }, true},
+		{"in epsilon zero", func(tb ut.T) { ut.InEpsilon(tb, 0, 0.0001, 0.01) }, true},
+		{"greater", func(tb ut.T) { ut.Greater(tb, 2, 1.5) }, false},
+		{"greater equal", func(tb ut.T) { ut.Greater(tb, 2, 2) }, true},
+		{"greater signed and unsigned", func(tb ut.T) { ut.Greater(tb, uint64(math.MaxUint64), -1) }, false},
+		{"greater large ints", func(tb ut.T) { ut.Greater(tb, int64(math.MaxInt64), int64(math.MaxInt64-1)) }, false},
+		{"greater times", func(tb ut.T) { ut.Greater(tb, now.Add(time.Second), now) }, false},
+		{"greater time and number", func(tb ut.T) { ut.Greater(tb, now, 1) }, true},
+		{"less", func(tb ut.T) { ut.Less(tb, time.Millisecond, time.Second) }, false},
+		{"less negative and unsigned", func(tb ut.T) { ut.Less(tb, uint(0), -1) }, true},
+		{"less NaN", func(tb ut.T) { ut.Less(tb, math.NaN(), 1) }, true},
+		{"between", func(tb ut.T) { ut.Between(tb, 5, 5, 10) }, false},
+		{"between times", func(tb ut.T) { ut.Between(tb, now, now.Add(-time.Second), now.Add(time.Second)) }, false},
+		{"between out of range", func(tb ut.T) { ut.Between(tb, 10.5, 5, 10) }, true},
+		{"between strings", func(tb ut.T) { ut.Between(tb, "b", "a", "c") }, true},
+	}
+
+	for _, test := range tests {
+		ft := new(fakeT)
+		test.check(ft)
+		if ft.fail != test.fail {
+			t.Fatalf("%s: expected failure to be %v, got %v:\n%s", test.name, test.fail, ft.fail, ft.Output())
+		}
+	}
+
+	ft := new(fakeT)
+	ut.InDelta(ft, 1.0, 1.5, 0.1)
+	if output := ft.Output(); !strings.Contains(output, "difference 0.5 exceeds delta 0.1") {
+		t.Fatalf("Expected the difference to be reported, got:\n%s", output)
+	}
+}
+
+type stats struct {
+	Name  string
+	Mean  float64
+	Parts []struct{ Ratio float64 }
+}
+
+func TestEqualsKeyTolerance(t *testing.T) {
+	actual := stats{Name: "div", Mean: 3.3333333, Parts: []struct{ Ratio float64 }{{0.1 + 0.2}}}
+	dir := tempTestdata(t)
+	golden := map[string]json.RawMessage{"stats": []byte(`{"Name": "div", "Mean": 3.3333, "Parts": [{"Ratio": 0.3}]}`)}
+
+	ft, early, _ := MetaTester("EqualsKeyTolerance", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = golden
+		tt.EqualsKey("stats", actual, ut.FloatEpsilon(0.001))
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected floats within the tolerance to be equal, got:\n%s", ft.Output())
+	}
+
+	ft, early, _ = MetaTester("EqualsKeyTolerance", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = golden
+		tt.EqualsKey("stats", actual, ut.FloatEpsilon(1e-9))
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected floats beyond the tolerance to fail the test")
+	}
+	if output := ft.Output(); !strings.Contains(output, ".Mean: 3.3333 != 3.3333333") || strings.Contains(output, ".Ratio") {
+		t.Fatalf("Expected only the float beyond the tolerance to be reported, got:\n%s", output)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+// Option customizes a single check, e.g. the codec EqualsFile uses
+// to store the golden result
+type Option func(*options)
+
+// options holds the settings a check runs with
+type options struct {
+	codec    Codec
+	json     jsonOptions
+	equality equalityOptions
+	message  string
+}
+
+// Msg adds context to the failure report of a check taking options, such as
+// the iteration of a loop it was made in. It is an alternative to giving the
+// message and its arguments after the options, for code that builds a []Option
+func Msg(msg string, args ...interface{}) Option {
+	return func(o *options) {
+		o.message = formatMessage(append([]interface{}{msg}, args...))
+	}
+}
+
+// newOptions returns the settings resulting from applying opts over the defaults
+func newOptions(defaults options, opts []Option) *options {
+	o := &defaults
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.codec == nil {
+		o.codec = JSONCodec
+	}
+	return o
+}
+
+// options returns the settings for a check, starting from the
+// TestTools defaults and applying the given options
+func (tt *TestTools) options(opts []Option) *options {
+	return newOptions(options{codec: tt.Codec, equality: equalityOptions{comparers: tt.comparers}}, opts)
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OrphansEnv is the environment variable that enables orphan detection:
+// off (default), report or fail
+const OrphansEnv = "UT_ORPHANS"
+
+// RecordEnv is the environment variable that, when set to a directory, makes
+// every passing test write there a Record of the golden data it used.
+// It is meant to be used by tools such as `ut prune`
+const RecordEnv = "UT_RECORD"
+
+// Orphan detection modes
+const (
+	OrphansOff    = "off"
+	OrphansReport = "report"
+	OrphansFail   = "fail"
+)
+
+// Record lists the golden data a test used
+type Record struct {
+	TestdataDir string   `json:"testdataDir"`
+	Files       []string `json:"files"`
+	Keys        []string `json:"keys"`
+}
+
+// orphansMode returns the configured orphan detection mode
+func orphansMode() string {
+	mode := os.Getenv(OrphansEnv)
+	if mode == "" {
+		mode = OrphansOff
+	}
+	return mode
+}
+
+// touchFile marks a golden file in the test's testdata folder as used
+func (tt *TestTools) touchFile(file string) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.touchedFiles == nil {
+		tt.touchedFiles = make(map[string]bool)
+	}
+	tt.touchedFiles[filepath.Clean(file)] = true
+}
+
+// touchKey marks a results.json key as used
+func (tt *TestTools) touchKey(key string) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.touchedKeys == nil {
+		tt.touchedKeys = make(map[string]bool)
+	}
+	tt.touchedKeys[key] = true
+}
+
+// record returns the golden data used so far by the test
+func (tt *TestTools) record() *Record {
+	r := &Record{
+		TestdataDir: tt.TestdataDir,
+		Files:       sortedKeys(tt.touchedFiles),
+		Keys:        sortedKeys(tt.touchedKeys),
+	}
+	return r
+}
+
+// checkOrphans reports, fails on or records golden data not used by the test
+func (tt *TestTools) checkOrphans() {
+	if tt.parent != nil {
+		// subtests share their parent's testdata folder, which checks it when it finishes
+		for file := range tt.touchedFiles {
+			tt.parent.touchFile(file)
+		}
+		for key := range tt.touchedKeys {
+			tt.parent.touchKey(key)
+		}
+		return
+	}
+	r := tt.record()
+
+	if dir := os.Getenv(RecordEnv); dir != "" {
+		tt.writeRecord(dir, r)
+	}
+
+	mode := orphansMode()
+	if mode == OrphansOff {
+		return
+	}
+
+	var keys []string
+	for key := range tt.Results {
+		if !tt.touchedKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	files, _, err := FindOrphans(r.TestdataDir, r.Files, nil)
+	if err != nil {
+		tt.T.Logf("Cannot check testdata/%s for orphaned golden data: %s", tt.T.Name(), err)
+		return
+	}
+	if len(files) == 0 && len(keys) == 0 {
+		return
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Orphaned golden data in testdata/%s:", tt.T.Name())
+	for _, file := range files {
+		fmt.Fprintf(&buf, "\n\tfile '%s'", file)
+	}
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "\n\tkey '%s' in results.json", key)
+	}
+	tt.T.Log(buf.String())
+	if mode == OrphansFail {
+		tt.T.Fatalf("Found %d orphaned golden files and %d orphaned results.json keys", len(files), len(keys))
+	}
+}
+
+func (tt *TestTools) writeRecord(dir string, r *Record) {
+	recordBytes, err := json.Marshal(r)
+	if err != nil {
+		tt.T.Logf("Cannot marshal golden data record: %s", err)
+		return
+	}
+	f, err := ioutil.TempFile(dir, "record-*.json")
+	if err != nil {
+		tt.T.Logf("Cannot create golden data record in %s: %s", dir, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(recordBytes); err != nil {
+		tt.T.Logf("Cannot write golden data record %s: %s", f.Name(), err)
+	}
+}
+
+// FindOrphans returns the files in testdataDir and the keys of its results.json
+// that are not among the given used files and keys.
+// Subfolders are only inspected if some used file lives in them, since
+// untouched subfolders usually belong to subtests.
+func FindOrphans(testdataDir string, files, keys []string) (orphanFiles, orphanKeys []string, err error) {
+	used := make(map[string]bool)
+	usedDirs := make(map[string]bool)
+	for _, file := range files {
+		file = filepath.Clean(filepath.FromSlash(file))
+		used[file] = true
+		for dir := filepath.Dir(file); dir != "."; dir = filepath.Dir(dir) {
+			usedDirs[dir] = true
+		}
+	}
+
+	err = filepath.Walk(testdataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == testdataDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(testdataDir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel != "." && !usedDirs[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel == "results.json" || used[rel] || isTestdataHidden(rel) {
+			return nil
+		}
+		orphanFiles = append(orphanFiles, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if keys != nil {
+		var results results
+		resultBytes, err := ioutil.ReadFile(filepath.Join(testdataDir, "results.json"))
+		if err == nil {
+			if err := json.Unmarshal(resultBytes, &results); err != nil {
+				return nil, nil, fmt.Errorf("cannot decode results.json: %s", err)
+			}
+		}
+		usedKeys := make(map[string]bool)
+		for _, key := range keys {
+			usedKeys[key] = true
+		}
+		for key := range results {
+			if !usedKeys[key] {
+				orphanKeys = append(orphanKeys, key)
+			}
+		}
+		sort.Strings(orphanKeys)
+	}
+	return orphanFiles, orphanKeys, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, filepath.ToSlash(k))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isTestdataHidden tells whether a testdata file is bookkeeping rather than golden data
+func isTestdataHidden(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), ".") || strings.HasSuffix(name, ReceivedSuffix)
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestFindOrphans(t *testing.T) {
+	dir := tempTestdata(t)
+
+	ut.CreateDirectory(filepath.Join(dir, "used"))
+	ut.CreateDirectory(filepath.Join(dir, "Subtest"))
+	for _, name := range []string{"a.json", "b.json", "used/c.txt", "used/d.txt", "Subtest/e.txt", ".gitkeep"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := ioutil.WriteFile(filepath.Join(dir, "results.json"), []byte(`{"x": 1, "y": 2}`), 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, keys, err := ut.FindOrphans(dir, []string{"a.json", "used/c.txt"}, []string{"y"})
+	ut.Ok(t, err)
+	ut.Equals(t, []string{"b.json", "used/d.txt"}, files)
+	ut.Equals(t, []string{"x"}, keys)
+}
+
+func TestOrphansFail(t *testing.T) {
+	dir := tempTestdata(t)
+
+	for _, name := range []string{"used.txt", "unused.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("text"), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Setenv(ut.OrphansEnv, ut.OrphansFail)
+	defer os.Unsetenv(ut.OrphansEnv)
+
+	ft, _, _ := MetaTester("OrphansFail", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("used.txt", "text")
+	})
+	if !ft.fail {
+		t.Fatalf("Expected orphaned golden files to fail the test")
+	}
+
+	ft, _, _ = MetaTester("OrphansFail", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("used.txt", "text")
+		tt.EqualsTextFile("unused.txt", "text")
+	})
+	if ft.fail {
+		t.Fatalf("Expected test using all its golden files to pass")
+	}
+}
+
+func TestOrphansOptIn(t *testing.T) {
+	dir := tempTestdata(t)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "unused.txt"), []byte("text"), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	ft, _, _ := MetaTester("OrphansOptIn", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+	})
+	if ft.fail || strings.Contains(ft.Output(), "Orphaned") {
+		t.Fatalf("Expected orphans not to be checked unless enabled, got:\n%s", ft.Output())
+	}
+
+	os.Setenv(ut.OrphansEnv, ut.OrphansReport)
+	defer os.Unsetenv(ut.OrphansEnv)
+
+	ft, _, _ = MetaTester("OrphansOptIn", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+	})
+	if ft.fail || !strings.Contains(ft.Output(), "file 'unused.txt'") {
+		t.Fatalf("Expected orphans to be reported, got:\n%s", ft.Output())
+	}
+}
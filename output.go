@@ -0,0 +1,57 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"sync"
+)
+
+// Failure is a structured record of a failed check
+type Failure struct {
+	Test    string // name of the test, empty if the check was not bound to one
+	File    string // location of the failed check
+	Line    int
+	Message string // the diagnostic also logged through the test
+}
+
+// Sink receives every failed check, so that custom test runners can collect
+// them. Failures are still logged through the test as well
+type Sink interface {
+	Failure(f *Failure)
+}
+
+var sink struct {
+	sync.RWMutex
+	s Sink
+}
+
+// SetSink registers the sink that receives failed checks, or removes it if s is nil
+func SetSink(s Sink) {
+	sink.Lock()
+	defer sink.Unlock()
+	sink.s = s
+}
+
+// notifySink hands a failure to the registered sink, if any
+func notifySink(f *Failure) {
+	sink.RLock()
+	s := sink.s
+	sink.RUnlock()
+	if s != nil {
+		s.Failure(f)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type failureSink struct {
+	failures []*ut.Failure
+}
+
+func (s *failureSink) Failure(f *ut.Failure) {
+	s.failures = append(s.failures, f)
+}
+
+// locationT is a fakeT that, like testing.T, prefixes what it logs with the
+// location of the first caller outside this library
+type locationT struct {
+	fakeT
+}
+
+func (t *locationT) Helper() {}
+
+func (t *locationT) Log(args ...interface{}) {
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/epiclabs-io/ut.") || !more {
+			t.fakeT.Log(fmt.Sprintf("%s:%d: %s", filepath.Base(frame.File), frame.Line, fmt.Sprint(args...)))
+			return
+		}
+	}
+}
+
+func TestLocationOnce(t *testing.T) {
+	sink := new(failureSink)
+	ut.SetSink(sink)
+	defer ut.SetSink(nil)
+
+	lt := new(locationT)
+	_, _, line, _ := runtime.Caller(0)
+	ut.Equals(lt, 1, 2, "iteration %d", 4)
+	location := fmt.Sprintf("output_test.go:%d:", line+1)
+	if output := lt.Output(); !strings.HasPrefix(output, location+" iteration 4") || strings.Count(output, "output_test.go") != 1 {
+		t.Fatalf("Expected the location to be reported once, got:\n%s", output)
+	}
+	ut.Equals(t, 1, len(sink.failures))
+	ut.Equals(t, line+1, sink.failures[0].Line)
+}
+
+func TestOutputThroughT(t *testing.T) {
+	sink := new(failureSink)
+	ut.SetSink(sink)
+	defer ut.SetSink(nil)
+
+	ft, early, _ := MetaTester("OutputThroughT", func(tt *ut.TestTools) {
+		tt.Equals(1, 2)
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected the test to fail")
+	}
+	output := ft.Output()
+	if !strings.Contains(output, "output_test.go:") || !strings.Contains(output, "\texpected: 1\n\n\tgot: 2") {
+		t.Fatalf("Expected the failure to be logged through the test, got:\n%s", output)
+	}
+
+	if len(sink.failures) != 1 {
+		t.Fatalf("Expected the sink to receive 1 failure, got %d", len(sink.failures))
+	}
+	f := sink.failures[0]
+	ut.Equals(t, "OutputThroughT", f.Test)
+	ut.Equals(t, "output_test.go", filepath.Base(f.File))
+	ut.Assert(t, f.Line > 0, "Expected the failure line to be set")
+	ut.Equals(t, "expected: 1\n\n\tgot: 2", strings.TrimSpace(f.Message))
+}
+
+func TestAssertionMessage(t *testing.T) {
+	ft, early, _ := MetaTester("AssertionMessage", func(tt *ut.TestTools) {
+		for i, n := range []int{2, 4, 5} {
+			tt.Equals(0, n%2, "item %d", i)
+		}
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected the test to fail")
+	}
+	if output := ft.Output(); !strings.Contains(output, ": item 2\n\n\texpected: 0\n\n\tgot: 1") {
+		t.Fatalf("Expected the failure to include the message, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.Ok(ft, errors.New("boom"), "loading %s", "config")
+	if output := ft.Output(); !strings.Contains(output, ": loading config: unexpected error: boom") {
+		t.Fatalf("Expected the failure to include the message, got:\n%s", output)
+	}
+
+	ft = new(fakeT)
+	ut.JSONEquals(ft, []byte(`{"a": 1}`), []byte(`{"a": 2}`), ut.IgnorePaths("/b"), "case %q", "a")
+	if output := ft.Output(); !strings.Contains(output, `: case "a"`+"\n") {
+		t.Fatalf("Expected the failure to include the message, got:\n%s", output)
+	}
+
+	dir := tempTestdata(t)
+	ft, early, _ = MetaTester("AssertionMessage", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"n": []byte("1")}
+		tt.EqualsKey("n", 2, ut.FloatEpsilon(0.5), "iteration %d", 3)
+	})
+	if !early || !ft.fail || !strings.Contains(ft.Output(), ": iteration 3\n") {
+		t.Fatalf("Expected the failure to include the message, got:\n%s", ft.Output())
+	}
+
+	ft, early, _ = MetaTester("AssertionMessage", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"n": []byte("1")}
+		tt.EqualsKey("n", 2, ut.Msg("iteration %d", 4))
+	})
+	if !early || !ft.fail || !strings.Contains(ft.Output(), ": iteration 4\n") {
+		t.Fatalf("Expected the failure to include the message, got:\n%s", ft.Output())
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Eventually checks condition every interval until it returns true. If it
+// doesn't within timeout, it fails the test reporting the number of attempts
+func (tt *TestTools) Eventually(timeout, interval time.Duration, condition func() bool, msg string, v ...interface{}) {
+	helperOf(tt.T)()
+	tt.checkInterval("Eventually", interval)
+	ok, attempts := poll(timeout, interval, condition)
+	if !ok {
+		tt.in().failf(0, "Eventually: condition not met within %s after %d attempts: %s", timeout, attempts, fmt.Sprintf(msg, v...))
+		tt.Error(fmt.Errorf("Condition not met: %s", fmt.Sprintf(msg, v...)))
+	}
+}
+
+// Consistently checks condition every interval for the given duration,
+// failing the test as soon as it returns false
+func (tt *TestTools) Consistently(duration, interval time.Duration, condition func() bool, msg string, v ...interface{}) {
+	helperOf(tt.T)()
+	tt.checkInterval("Consistently", interval)
+	broken, attempts := poll(duration, interval, func() bool { return !condition() })
+	if broken {
+		tt.in().failf(0, "Consistently: condition no longer met after %d attempts: %s", attempts, fmt.Sprintf(msg, v...))
+		tt.Error(fmt.Errorf("Condition not met: %s", fmt.Sprintf(msg, v...)))
+	}
+}
+
+// EventuallyBlock runs block every interval until none of the checks it makes
+// fails. Failed checks don't fail the test but make the block run again.
+// If the block still fails after timeout, the test fails reporting the number
+// of attempts and the failures of the last one
+func (tt *TestTools) EventuallyBlock(timeout, interval time.Duration, block func(tt *TestTools), msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	tt.checkInterval("EventuallyBlock", interval)
+	var last *attemptT
+	ok, attempts := poll(timeout, interval, func() bool {
+		last = tt.attempt(block)
+		return !last.Failed()
+	})
+	if !ok {
+		tt.in().WithMessage(msgAndArgs...).failf(0, "EventuallyBlock: block still failing after %s and %d attempts. Last attempt:\n%s",
+			timeout, attempts, last.Output())
+		tt.Error(errors.New("Block kept failing"))
+	}
+}
+
+// ConsistentlyBlock runs block every interval for the given duration, failing
+// the test as soon as one of the checks it makes fails
+func (tt *TestTools) ConsistentlyBlock(duration, interval time.Duration, block func(tt *TestTools), msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	tt.checkInterval("ConsistentlyBlock", interval)
+	var last *attemptT
+	broken, attempts := poll(duration, interval, func() bool {
+		last = tt.attempt(block)
+		return last.Failed()
+	})
+	if broken {
+		tt.in().WithMessage(msgAndArgs...).failf(0, "ConsistentlyBlock: block failed at attempt %d:\n%s", attempts, last.Output())
+		tt.Error(errors.New("Block failed"))
+	}
+}
+
+// checkInterval stops the test if interval would make a check poll in a busy loop
+func (tt *TestTools) checkInterval(check string, interval time.Duration) {
+	helperOf(tt.T)()
+	if interval <= 0 {
+		tt.Fatalf("%s: interval must be positive, got %s", check, interval)
+	}
+}
+
+// poll calls check every interval until it returns true or timeout elapses,
+// returning whether it did and how many times it was called. check is called
+// at least once, and one last time when timeout elapses
+func poll(timeout, interval time.Duration, check func() bool) (bool, int) {
+	deadline := time.Now().Add(timeout)
+	for attempts := 1; ; attempts++ {
+		if check() {
+			return true, attempts
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, attempts
+		}
+		if interval < remaining {
+			remaining = interval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// attempt runs block once with TestTools whose failures are recorded
+// instead of failing the test, returning them
+func (tt *TestTools) attempt(block func(tt *TestTools)) *attemptT {
+	helperOf(tt.T)()
+	t := &attemptT{name: tt.T.Name()}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if e := recover(); e != nil {
+				t.Errorf("panic: %v", e)
+			}
+		}()
+		sub := tt.subTools(t)
+		defer sub.finishAttempt()
+		block(sub)
+	}()
+	<-done
+	return t
+}
+
+// finishAttempt cleans up after a block run by attempt, handing the golden
+// data it used over to its parent. Unlike FinishTest, failed checks only
+// mark the block's T as failed, since their messages were already recorded
+func (tt *TestTools) finishAttempt() {
+	tt.W.Wait()
+	close(tt.err)
+	for err := range tt.err {
+		if err != nil {
+			tt.T.Fail()
+		}
+	}
+	tt.closeServices()
+	if tt.resultsDirty {
+		tt.parent.resultsDirty = true
+	}
+	tt.saveReceivedKeys()
+	tt.checkOrphans()
+}
+
+// attemptT is the T a block run by EventuallyBlock or ConsistentlyBlock
+// reports to. FailNow ends the attempt rather than the test, and failures
+// are not handed to the Sink, since the attempt may be retried
+type attemptT struct {
+	mu     sync.Mutex
+	name   string
+	failed bool
+	output []string
+}
+
+func (t *attemptT) Error(args ...interface{}) {
+	t.Log(args...)
+	t.Fail()
+}
+
+func (t *attemptT) Errorf(format string, args ...interface{}) {
+	t.Logf(format, args...)
+	t.Fail()
+}
+
+func (t *attemptT) Fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+}
+
+func (t *attemptT) FailNow() {
+	t.Fail()
+	runtime.Goexit()
+}
+
+func (t *attemptT) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+func (t *attemptT) Fatal(args ...interface{}) {
+	t.Log(args...)
+	t.FailNow()
+}
+
+func (t *attemptT) Fatalf(format string, args ...interface{}) {
+	t.Logf(format, args...)
+	t.FailNow()
+}
+
+func (t *attemptT) Log(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.output = append(t.output, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (t *attemptT) Logf(format string, args ...interface{}) {
+	t.Log(fmt.Sprintf(format, args...))
+}
+
+func (t *attemptT) Name() string {
+	return t.name
+}
+
+// Output returns what was logged during the attempt, indented
+func (t *attemptT) Output() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return "\t" + strings.Replace(strings.Join(t.output, "\n"), "\n", "\n\t", -1)
+}
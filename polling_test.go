@@ -0,0 +1,143 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestEventually(t *testing.T) {
+	var counter int32
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&counter, 1)
+		}
+	}()
+	ft, early, _ := MetaTester("Eventually", func(tt *ut.TestTools) {
+		tt.Eventually(time.Second, time.Millisecond, func() bool {
+			return atomic.LoadInt32(&counter) == 3
+		}, "counter should reach 3")
+		tt.EventuallyBlock(time.Second, time.Millisecond, func(tt *ut.TestTools) {
+			tt.Equals(int32(3), atomic.LoadInt32(&counter))
+		})
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected the conditions to be met eventually, got:\n%s", ft.Output())
+	}
+
+	ft, early, _ = MetaTester("Eventually", func(tt *ut.TestTools) {
+		tt.Eventually(20*time.Millisecond, 5*time.Millisecond, func() bool { return false }, "never %s", "true")
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected a condition never met to fail the test")
+	}
+	if output := ft.Output(); !strings.Contains(output, "Eventually: condition not met within 20ms after") ||
+		!strings.Contains(output, "attempts: never true") {
+		t.Fatalf("Expected the number of attempts to be reported, got:\n%s", output)
+	}
+
+	attempts := 0
+	ft, early, _ = MetaTester("Eventually", func(tt *ut.TestTools) {
+		tt.EventuallyBlock(20*time.Millisecond, 5*time.Millisecond, func(tt *ut.TestTools) {
+			attempts++
+			tt.Equals(0, attempts, "attempt %d", attempts)
+		}, "counting")
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected a block always failing to fail the test")
+	}
+	output := ft.Output()
+	if !strings.Contains(output, "counting: EventuallyBlock: block still failing after 20ms and") ||
+		!strings.Contains(output, fmt.Sprintf("attempt %d\n", attempts)) || strings.Contains(output, "attempt 1\n") {
+		t.Fatalf("Expected the last failure to be reported, got:\n%s", output)
+	}
+}
+
+func TestConsistently(t *testing.T) {
+	ft, early, _ := MetaTester("Consistently", func(tt *ut.TestTools) {
+		tt.Consistently(10*time.Millisecond, time.Millisecond, func() bool { return true }, "always true")
+		tt.ConsistentlyBlock(10*time.Millisecond, time.Millisecond, func(tt *ut.TestTools) {
+			tt.Assert(true, "always true")
+		})
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected the conditions to hold, got:\n%s", ft.Output())
+	}
+
+	calls := 0
+	ft, early, _ = MetaTester("Consistently", func(tt *ut.TestTools) {
+		tt.ConsistentlyBlock(time.Second, time.Millisecond, func(tt *ut.TestTools) {
+			calls++
+			tt.Assert(calls < 3, "call %d", calls)
+		})
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected a block failing once to fail the test")
+	}
+	if output := ft.Output(); !strings.Contains(output, "ConsistentlyBlock: block failed at attempt 3:") ||
+		!strings.Contains(output, "Assertion failed: call 3") {
+		t.Fatalf("Expected the failing attempt to be reported, got:\n%s", output)
+	}
+}
+
+func TestPollingSink(t *testing.T) {
+	sink := new(failureSink)
+	ut.SetSink(sink)
+	defer ut.SetSink(nil)
+
+	attempts := 0
+	ft, early, _ := MetaTester("PollingSink", func(tt *ut.TestTools) {
+		tt.EventuallyBlock(time.Second, time.Millisecond, func(tt *ut.TestTools) {
+			attempts++
+			tt.Assert(attempts == 3, "attempt %d", attempts)
+		})
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected the block to pass eventually, got:\n%s", ft.Output())
+	}
+	ut.Equals(t, 0, len(sink.failures))
+
+	ft, early, _ = MetaTester("PollingSink", func(tt *ut.TestTools) {
+		tt.EventuallyBlock(10*time.Millisecond, time.Millisecond, func(tt *ut.TestTools) {
+			tt.Assert(false, "never")
+		})
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected a block always failing to fail the test")
+	}
+	ut.Equals(t, 1, len(sink.failures))
+	ut.Assert(t, strings.HasPrefix(sink.failures[0].Message, "EventuallyBlock: block still failing"),
+		"Expected only the final failure to be sent, got %q", sink.failures[0].Message)
+}
+
+func TestPollingInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Millisecond} {
+		ft, early, _ := MetaTester("PollingInterval", func(tt *ut.TestTools) {
+			tt.Eventually(time.Second, interval, func() bool { return true }, "true")
+		})
+		if !early || !ft.fail || !strings.Contains(ft.Output(), "Eventually: interval must be positive, got "+interval.String()) {
+			t.Fatalf("Expected an interval of %s to fail the test, got:\n%s", interval, ft.Output())
+		}
+	}
+}
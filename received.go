@@ -0,0 +1,207 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReceivedSuffix is appended to the name of a golden file to store the actual
+// value received by a failed comparison, so it can be reviewed and approved
+const ReceivedSuffix = ".received"
+
+// writeReceived stores the actual value of a failed comparison next to its golden file
+func (tt *TestTools) writeReceived(path string, data []byte) {
+	helperOf(tt.T)()
+	receivedPath := path + ReceivedSuffix
+	CreateDirectory(filepath.Dir(receivedPath))
+	err := ioutil.WriteFile(receivedPath, data, 0660)
+	if err != nil {
+		tt.T.Logf("Cannot write received file %s : %s", receivedPath, err)
+		return
+	}
+	tt.T.Logf("Actual value written to %s", receivedPath)
+}
+
+// removeReceived deletes the outdated received file of a golden file, if any
+func removeReceived(path string) {
+	os.Remove(path + ReceivedSuffix)
+}
+
+// receiveKey stores the actual value of a failed EqualsKey comparison
+// to be written to results.json.received when the test finishes
+func (tt *TestTools) receiveKey(key string, data []byte) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.receivedKeys == nil {
+		tt.receivedKeys = make(results)
+	}
+	tt.receivedKeys[key] = data
+}
+
+// saveReceivedKeys writes the actual values of the failed EqualsKey comparisons
+// to results.json.received, or removes it if there were none
+func (tt *TestTools) saveReceivedKeys() {
+	if tt.parent != nil {
+		for key, data := range tt.receivedKeys {
+			tt.parent.receiveKey(key, data)
+		}
+		return
+	}
+	path := filepath.Join(tt.TestdataDir, "results.json")
+	if len(tt.receivedKeys) == 0 {
+		removeReceived(path)
+		return
+	}
+	receivedBytes, err := json.MarshalIndent(tt.receivedKeys, "", "\t")
+	if err != nil {
+		tt.T.Logf("Cannot marshal received keys: %s", err)
+		return
+	}
+	tt.writeReceived(path, receivedBytes)
+}
+
+// FindReceived returns the received files pending review under root
+func FindReceived(root string) ([]string, error) {
+	var received []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != root && (strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ReceivedSuffix) {
+			received = append(received, path)
+		}
+		return nil
+	})
+	sort.Strings(received)
+	return received, err
+}
+
+// goldenPath returns the golden file a received file belongs to
+func goldenPath(receivedPath string) (string, error) {
+	if !strings.HasSuffix(receivedPath, ReceivedSuffix) {
+		return "", fmt.Errorf("%s is not a received file", receivedPath)
+	}
+	return strings.TrimSuffix(receivedPath, ReceivedSuffix), nil
+}
+
+// isResultsFile tells whether path is a results.json file holding golden keys
+func isResultsFile(path string) bool {
+	return filepath.Base(path) == "results.json"
+}
+
+// DiffReceived describes the differences between a received file and its golden file
+func DiffReceived(receivedPath string) (string, error) {
+	path, err := goldenPath(receivedPath)
+	if err != nil {
+		return "", err
+	}
+	received, err := ioutil.ReadFile(receivedPath)
+	if err != nil {
+		return "", err
+	}
+	golden, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if !isResultsFile(path) {
+		if isBinary(golden) || isBinary(received) {
+			return hexdumpDiff(golden, received, firstDifference(golden, received)), nil
+		}
+		return textDiff(string(golden), string(received)), nil
+	}
+
+	var goldenKeys, receivedKeys results
+	if len(golden) > 0 {
+		if err := json.Unmarshal(golden, &goldenKeys); err != nil {
+			return "", fmt.Errorf("cannot decode %s: %s", path, err)
+		}
+	}
+	if err := json.Unmarshal(received, &receivedKeys); err != nil {
+		return "", fmt.Errorf("cannot decode %s: %s", receivedPath, err)
+	}
+	var diff strings.Builder
+	keys := make([]string, 0, len(receivedKeys))
+	for key := range receivedKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&diff, "key '%s':\n%s\n", key,
+			textDiff(string(Internal.JSONPretty(goldenKeys[key])), string(Internal.JSONPretty(receivedKeys[key]))))
+	}
+	return diff.String(), nil
+}
+
+// ApproveReceived makes a received file the new golden file. Received
+// results.json keys are merged into results.json, keeping the other keys
+func ApproveReceived(receivedPath string) error {
+	path, err := goldenPath(receivedPath)
+	if err != nil {
+		return err
+	}
+	if !isResultsFile(path) {
+		return os.Rename(receivedPath, path)
+	}
+
+	received, err := ioutil.ReadFile(receivedPath)
+	if err != nil {
+		return err
+	}
+	var receivedKeys results
+	if err := json.Unmarshal(received, &receivedKeys); err != nil {
+		return fmt.Errorf("cannot decode %s: %s", receivedPath, err)
+	}
+	goldenKeys := make(results)
+	golden, err := ioutil.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(golden, &goldenKeys); err != nil {
+			return fmt.Errorf("cannot decode %s: %s", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for key, value := range receivedKeys {
+		goldenKeys[key] = value
+	}
+	resultsBytes, err := json.MarshalIndent(goldenKeys, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, resultsBytes, 0660); err != nil {
+		return err
+	}
+	return os.Remove(receivedPath)
+}
+
+// RejectReceived discards a received file, leaving its golden file untouched
+func RejectReceived(receivedPath string) error {
+	if _, err := goldenPath(receivedPath); err != nil {
+		return err
+	}
+	return os.Remove(receivedPath)
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestReceived(t *testing.T) {
+	dir := tempTestdata(t)
+
+	writeTree(t, dir, map[string]string{
+		"out.txt":      "expected",
+		"results.json": `{"a": 1, "b": 2}`,
+	})
+
+	MetaTester("Received", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"a": []byte("1"), "b": []byte("2")}
+		tt.Go(func() { tt.EqualsKey("a", 10) })
+		tt.W.Wait()
+		tt.EqualsTextFile("out.txt", "actual")
+	})
+
+	received, err := ut.FindReceived(dir)
+	ut.Ok(t, err)
+	ut.Equals(t, []string{
+		filepath.Join(dir, "out.txt.received"),
+		filepath.Join(dir, "results.json.received"),
+	}, received)
+
+	diff, err := ut.DiffReceived(received[0])
+	ut.Ok(t, err)
+	ut.Assert(t, diff != "", "Expected a diff for a received file")
+
+	for _, file := range received {
+		ut.Ok(t, ut.ApproveReceived(file))
+	}
+
+	ft, early, _ := MetaTester("Received", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.LoadResults()
+		tt.EqualsKey("a", 10)
+		tt.EqualsKey("b", 2)
+		tt.EqualsTextFile("out.txt", "actual")
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected approved received files to become the new golden data")
+	}
+	received, err = ut.FindReceived(dir)
+	ut.Ok(t, err)
+	ut.Equals(t, 0, len(received))
+}
+
+func TestReceivedConcurrently(t *testing.T) {
+	dir := tempTestdata(t)
+
+	MetaTester("ReceivedConcurrently", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.Results = map[string]json.RawMessage{"a": []byte("1"), "b": []byte("2")}
+		tt.Go(func() { tt.EqualsKey("a", 10) })
+		tt.Go(func() { tt.EqualsKey("b", 20) })
+		tt.W.Wait()
+	})
+
+	receivedBytes, err := ioutil.ReadFile(filepath.Join(dir, "results.json.received"))
+	ut.Ok(t, err)
+	ut.JSONEquals(t, []byte(`{"a": 10, "b": 20}`), receivedBytes)
+}
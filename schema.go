@@ -0,0 +1,984 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaRefDepth limits how many $ref can be followed without moving
+// into the instance, which only happens with circular references
+const maxSchemaRefDepth = 100
+
+// JSONMatchesSchema fails the test if actual, once marshalled to JSON, does not
+// validate against the JSON Schema (draft-07 or draft 2020-12) in the given file.
+// The schema is looked up like input files, see LoadFile, and so are the files
+// referenced by its $ref, relative to the schema. Remote references are not
+// supported. If actual is a []byte or json.RawMessage it is taken as JSON already
+func (tt *TestTools) JSONMatchesSchema(schemaFile string, actual interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	actualBytes, err := schemaInstance(actual)
+	if err != nil {
+		tt.Fatalf("Cannot marshal 'actual' to JSON: %s", err)
+	}
+	load := func(name string) ([]byte, error) {
+		data, err := ioutil.ReadFile(filepath.Join(tt.TestdataDir, filepath.FromSlash(name)))
+		if err == nil {
+			tt.touchFile(name)
+			return data, nil
+		}
+		if !os.IsNotExist(err) || tt.SharedTestdataDir == "" {
+			return nil, err
+		}
+		return ioutil.ReadFile(filepath.Join(tt.SharedTestdataDir, filepath.FromSlash(name)))
+	}
+	if tt.in().WithMessage(msgAndArgs...).notMatchesSchema(0, schemaFile, load, actualBytes) {
+		tt.Error(errors.New("JSON does not match schema"))
+	}
+}
+
+// NotMatchesSchema validates a JSON document against the JSON Schema stored in
+// schemaPath, resolving $ref to other files relative to it
+func (in *internal) NotMatchesSchema(callDepth int, schemaPath string, actual []byte) bool {
+	helperOf(in.t)()
+	dir := filepath.Dir(schemaPath)
+	load := func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	return in.notMatchesSchema(callDepth+1, filepath.ToSlash(filepath.Base(schemaPath)), load, actual)
+}
+
+// notMatchesSchema prints every violation of the schema found in actual
+func (in *internal) notMatchesSchema(callDepth int, schemaFile string, load func(name string) ([]byte, error), actual []byte) bool {
+	helperOf(in.t)()
+	var instance interface{}
+	if err := json.Unmarshal(actual, &instance); err != nil {
+		in.failf(callDepth, "\n\n\tJSONMatchesSchema: Error decoding 'actual' JSON: %s.\n\tCan't decode this: `%s`", err, string(actual))
+		return true
+	}
+	v := &schemaValidator{load: load, docs: make(map[string]*schemaDoc), patterns: make(map[string]*regexp.Regexp)}
+	violations, err := v.validateFile(schemaFile, instance)
+	if err != nil {
+		in.failf(callDepth, "\n\n\tJSONMatchesSchema: invalid schema %s: %s", schemaFile, err)
+		return true
+	}
+	if len(violations) > 0 {
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "\n\n\tJSON does not match schema %s in %d places:\n", schemaFile, len(violations))
+		for _, violation := range violations {
+			fmt.Fprintf(&buf, "\t%s\n", violation.String())
+		}
+		in.failf(callDepth, "%s", buf.String())
+		return true
+	}
+	return false
+}
+
+// schemaInstance returns the JSON document to validate
+func schemaInstance(actual interface{}) ([]byte, error) {
+	switch a := actual.(type) {
+	case []byte:
+		return a, nil
+	case json.RawMessage:
+		return a, nil
+	}
+	return json.Marshal(actual)
+}
+
+// schemaViolation describes why an instance does not validate
+type schemaViolation struct {
+	instancePath string // JSON Pointer to the offending value
+	keywordPath  string // JSON Pointer to the failed keyword, following $ref
+	message      string
+}
+
+// String describes the violation, e.g. "/age: /properties/age/minimum: -1 is less than the minimum of 0"
+func (sv *schemaViolation) String() string {
+	instancePath := sv.instancePath
+	if instancePath == "" {
+		instancePath = "(root)"
+	}
+	return fmt.Sprintf("%s: %s: %s", instancePath, sv.keywordPath, sv.message)
+}
+
+// schemaDoc is a loaded schema file
+type schemaDoc struct {
+	name   string // slash separated, relative to the folder the schemas are loaded from
+	root   interface{}
+	draft7 bool // draft-07 and earlier ignore the keywords next to $ref
+}
+
+type schemaValidator struct {
+	load     func(name string) ([]byte, error)
+	docs     map[string]*schemaDoc
+	patterns map[string]*regexp.Regexp
+	err      error
+}
+
+// schemaScope is where validation stands: the document the current schema
+// lives in, the paths within the instance and the schema, and how many
+// $ref were followed without advancing into the instance
+type schemaScope struct {
+	doc          *schemaDoc
+	instancePath []string
+	keywordPath  []string
+	refDepth     int
+}
+
+func (s schemaScope) keyword(tokens ...string) schemaScope {
+	for _, token := range tokens {
+		s.keywordPath = appendToken(s.keywordPath, token)
+	}
+	return s
+}
+
+// sibling moves to another keyword of the same schema
+func (s schemaScope) sibling(keyword string) schemaScope {
+	s.keywordPath = appendToken(s.keywordPath[:len(s.keywordPath)-1], keyword)
+	return s
+}
+
+func (s schemaScope) child(token string, keywordTokens ...string) schemaScope {
+	s = s.keyword(keywordTokens...)
+	s.instancePath = appendToken(s.instancePath, token)
+	s.refDepth = 0
+	return s
+}
+
+func (s schemaScope) violation(format string, args ...interface{}) []schemaViolation {
+	return []schemaViolation{{
+		instancePath: formatJSONPointer(s.instancePath),
+		keywordPath:  formatJSONPointer(s.keywordPath),
+		message:      fmt.Sprintf(format, args...),
+	}}
+}
+
+// validateFile validates instance against the schema file, returning
+// the violations found sorted by instance path
+func (v *schemaValidator) validateFile(name string, instance interface{}) ([]schemaViolation, error) {
+	doc, err := v.loadDoc(path.Clean(name))
+	if err != nil {
+		return nil, err
+	}
+	violations := v.validate(schemaScope{doc: doc}, doc.root, instance)
+	if v.err != nil {
+		return nil, v.err
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return lessJSONPointer(violations[i].instancePath, violations[j].instancePath)
+	})
+	return violations, nil
+}
+
+func (v *schemaValidator) loadDoc(name string) (*schemaDoc, error) {
+	if doc, ok := v.docs[name]; ok {
+		return doc, nil
+	}
+	data, err := v.load(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load schema %s: %s", name, err)
+	}
+	doc := &schemaDoc{name: name}
+	if err := json.Unmarshal(data, &doc.root); err != nil {
+		return nil, fmt.Errorf("cannot decode schema %s: %s", name, jsonErrorPosition(data, err))
+	}
+	if m, ok := doc.root.(map[string]interface{}); ok {
+		if s, ok := m["$schema"].(string); ok {
+			doc.draft7 = strings.Contains(s, "draft-0")
+		}
+	}
+	v.docs[name] = doc
+	return doc, nil
+}
+
+// fail records a problem with the schema itself, which aborts validation
+func (v *schemaValidator) fail(s schemaScope, format string, args ...interface{}) []schemaViolation {
+	if v.err == nil {
+		v.err = fmt.Errorf("%s#%s: %s", s.doc.name, formatJSONPointer(s.keywordPath), fmt.Sprintf(format, args...))
+	}
+	return nil
+}
+
+// valid tells whether instance validates against schema
+func (v *schemaValidator) valid(s schemaScope, schema, instance interface{}) bool {
+	return len(v.validate(s, schema, instance)) == 0
+}
+
+func (v *schemaValidator) validate(s schemaScope, schema, instance interface{}) []schemaViolation {
+	if v.err != nil {
+		return nil
+	}
+	switch sch := schema.(type) {
+	case bool:
+		if !sch {
+			return s.violation("no value is allowed here")
+		}
+		return nil
+	case map[string]interface{}:
+		var violations []schemaViolation
+		if ref, ok := sch["$ref"].(string); ok {
+			violations = v.validateRef(s.keyword("$ref"), ref, instance)
+			if s.doc.draft7 {
+				return violations
+			}
+		}
+		for _, keyword := range sortedMapKeys(sch) {
+			check, ok := schemaKeywords[keyword]
+			if !ok {
+				if unsupportedSchemaKeywords[keyword] {
+					return v.fail(s.keyword(keyword), "keyword %s is not supported", keyword)
+				}
+				continue // annotations, $defs and unknown keywords
+			}
+			violations = append(violations, check(v, s.keyword(keyword), sch, sch[keyword], instance)...)
+		}
+		return violations
+	}
+	return v.fail(s, "a schema must be an object or a boolean, got %s", jsonValueString(schema))
+}
+
+// validateRef validates instance against the schema referenced by ref
+func (v *schemaValidator) validateRef(s schemaScope, ref string, instance interface{}) []schemaViolation {
+	if s.refDepth >= maxSchemaRefDepth {
+		if v.err == nil {
+			v.err = fmt.Errorf("%s: too many nested $ref at %q, is there a reference cycle?", s.doc.name, ref)
+		}
+		return nil
+	}
+	s.refDepth++
+	name, fragment := ref, ""
+	if i := strings.IndexByte(ref, '#'); i >= 0 {
+		name, fragment = ref[:i], ref[i+1:]
+	}
+	doc := s.doc
+	if name != "" {
+		local, err := v.localSchemaName(s.doc, name)
+		if err != nil {
+			return v.fail(s, "%s", err)
+		}
+		if doc, err = v.loadDoc(local); err != nil {
+			return v.fail(s, "%s", err)
+		}
+	}
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return v.fail(s, "invalid $ref %q: %s", ref, err)
+	}
+	target, ok := schemaFragment(doc.root, fragment)
+	if !ok {
+		return v.fail(s, "cannot resolve $ref %q", ref)
+	}
+	s.doc = doc
+	return v.validate(s, target, instance)
+}
+
+// localSchemaName maps a $ref to the name of a local schema file. Absolute
+// references are only accepted if they live under the base of the root
+// schema's $id, so that they can be mapped to a file next to it
+func (v *schemaValidator) localSchemaName(doc *schemaDoc, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid $ref %q: %s", ref, err)
+	}
+	if !u.IsAbs() {
+		return path.Join(path.Dir(doc.name), ref), nil
+	}
+	if root, ok := doc.root.(map[string]interface{}); ok {
+		if id, ok := root["$id"].(string); ok {
+			if base := id[:strings.LastIndexByte(id, '/')+1]; base != "" && strings.HasPrefix(ref, base) {
+				return path.Join(path.Dir(doc.name), ref[len(base):]), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot resolve remote $ref %q: only local schema files are supported", ref)
+}
+
+// schemaFragment finds the subschema a URI fragment points to, which is
+// either a JSON Pointer or a plain name defined with $anchor
+func schemaFragment(root interface{}, fragment string) (interface{}, bool) {
+	if fragment == "" {
+		return root, true
+	}
+	if strings.HasPrefix(fragment, "/") {
+		return resolveJSONPointer(root, fragment)
+	}
+	var found interface{}
+	walkJSON(root, nil, func(tokens []string, value interface{}) interface{} {
+		if m, ok := value.(map[string]interface{}); ok && found == nil {
+			if m["$anchor"] == fragment || m["$id"] == "#"+fragment {
+				found = m
+			}
+		}
+		return value
+	})
+	return found, found != nil
+}
+
+// unsupportedSchemaKeywords would make validation unreliable if ignored
+var unsupportedSchemaKeywords = map[string]bool{
+	"$dynamicRef":           true,
+	"$recursiveRef":         true,
+	"unevaluatedItems":      true,
+	"unevaluatedProperties": true,
+}
+
+type schemaKeyword func(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation
+
+var schemaKeywords map[string]schemaKeyword
+
+func init() {
+	schemaKeywords = map[string]schemaKeyword{
+		"type":                 checkSchemaType,
+		"enum":                 checkSchemaEnum,
+		"const":                checkSchemaConst,
+		"multipleOf":           checkSchemaMultipleOf,
+		"maximum":              checkSchemaMaximum,
+		"exclusiveMaximum":     checkSchemaExclusiveMaximum,
+		"minimum":              checkSchemaMinimum,
+		"exclusiveMinimum":     checkSchemaExclusiveMinimum,
+		"maxLength":            checkSchemaMaxLength,
+		"minLength":            checkSchemaMinLength,
+		"pattern":              checkSchemaPattern,
+		"prefixItems":          checkSchemaPrefixItems,
+		"items":                checkSchemaItems,
+		"additionalItems":      checkSchemaAdditionalItems,
+		"contains":             checkSchemaContains,
+		"maxItems":             checkSchemaMaxItems,
+		"minItems":             checkSchemaMinItems,
+		"uniqueItems":          checkSchemaUniqueItems,
+		"properties":           checkSchemaProperties,
+		"patternProperties":    checkSchemaPatternProperties,
+		"additionalProperties": checkSchemaAdditionalProperties,
+		"propertyNames":        checkSchemaPropertyNames,
+		"maxProperties":        checkSchemaMaxProperties,
+		"minProperties":        checkSchemaMinProperties,
+		"required":             checkSchemaRequired,
+		"dependentRequired":    checkSchemaDependentRequired,
+		"dependentSchemas":     checkSchemaDependentSchemas,
+		"dependencies":         checkSchemaDependencies,
+		"allOf":                checkSchemaAllOf,
+		"anyOf":                checkSchemaAnyOf,
+		"oneOf":                checkSchemaOneOf,
+		"not":                  checkSchemaNot,
+		"if":                   checkSchemaIf,
+	}
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value
+func jsonType(instance interface{}) string {
+	switch i := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if i == math.Trunc(i) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", instance)
+}
+
+func hasJSONType(instance interface{}, typ string) bool {
+	t := jsonType(instance)
+	return t == typ || typ == "number" && t == "integer"
+}
+
+func checkSchemaType(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	var types []string
+	switch t := value.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			typ, ok := item.(string)
+			if !ok {
+				return v.fail(s, "type must be a string or an array of strings")
+			}
+			types = append(types, typ)
+		}
+	default:
+		return v.fail(s, "type must be a string or an array of strings")
+	}
+	for _, typ := range types {
+		if hasJSONType(instance, typ) {
+			return nil
+		}
+	}
+	return s.violation("expected %s, got %s", strings.Join(types, " or "), jsonType(instance))
+}
+
+func checkSchemaEnum(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	values, ok := value.([]interface{})
+	if !ok {
+		return v.fail(s, "enum must be an array")
+	}
+	for _, allowed := range values {
+		if reflect.DeepEqual(allowed, instance) {
+			return nil
+		}
+	}
+	return s.violation("%s is not one of %s", jsonValueString(instance), jsonValueString(values))
+}
+
+func checkSchemaConst(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	if reflect.DeepEqual(value, instance) {
+		return nil
+	}
+	return s.violation("expected %s, got %s", jsonValueString(value), jsonValueString(instance))
+}
+
+// schemaNumber returns the numeric value of a keyword, recording a schema error if it is not a number
+func (v *schemaValidator) schemaNumber(s schemaScope, value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	if !ok {
+		v.fail(s, "must be a number")
+	}
+	return n, ok
+}
+
+// schemaCount returns the value of a keyword that must be a non-negative integer
+func (v *schemaValidator) schemaCount(s schemaScope, value interface{}) (int, bool) {
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		v.fail(s, "must be a non-negative integer")
+		return 0, false
+	}
+	return int(n), true
+}
+
+func checkSchemaMultipleOf(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	x, isNumber := instance.(float64)
+	m, ok := v.schemaNumber(s, value)
+	if !isNumber || !ok {
+		return nil
+	}
+	if m <= 0 {
+		return v.fail(s, "multipleOf must be greater than 0")
+	}
+	if q := x / m; math.Abs(q-math.Round(q)) > 1e-9 {
+		return s.violation("%s is not a multiple of %s", jsonValueString(x), jsonValueString(m))
+	}
+	return nil
+}
+
+func checkSchemaMaximum(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	x, isNumber := instance.(float64)
+	limit, ok := v.schemaNumber(s, value)
+	if isNumber && ok && x > limit {
+		return s.violation("%s is greater than the maximum of %s", jsonValueString(x), jsonValueString(limit))
+	}
+	return nil
+}
+
+func checkSchemaExclusiveMaximum(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	x, isNumber := instance.(float64)
+	limit, ok := v.schemaNumber(s, value)
+	if isNumber && ok && x >= limit {
+		return s.violation("%s is not less than %s", jsonValueString(x), jsonValueString(limit))
+	}
+	return nil
+}
+
+func checkSchemaMinimum(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	x, isNumber := instance.(float64)
+	limit, ok := v.schemaNumber(s, value)
+	if isNumber && ok && x < limit {
+		return s.violation("%s is less than the minimum of %s", jsonValueString(x), jsonValueString(limit))
+	}
+	return nil
+}
+
+func checkSchemaExclusiveMinimum(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	x, isNumber := instance.(float64)
+	limit, ok := v.schemaNumber(s, value)
+	if isNumber && ok && x <= limit {
+		return s.violation("%s is not greater than %s", jsonValueString(x), jsonValueString(limit))
+	}
+	return nil
+}
+
+func checkSchemaMaxLength(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	str, isString := instance.(string)
+	limit, ok := v.schemaCount(s, value)
+	if isString && ok && utf8.RuneCountInString(str) > limit {
+		return s.violation("%s is longer than %d characters", jsonValueString(str), limit)
+	}
+	return nil
+}
+
+func checkSchemaMinLength(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	str, isString := instance.(string)
+	limit, ok := v.schemaCount(s, value)
+	if isString && ok && utf8.RuneCountInString(str) < limit {
+		return s.violation("%s is shorter than %d characters", jsonValueString(str), limit)
+	}
+	return nil
+}
+
+// pattern compiles a regular expression keyword. Go's RE2 syntax covers the
+// ECMA-262 subset the specification recommends for interoperable schemas
+func (v *schemaValidator) pattern(s schemaScope, value interface{}) *regexp.Regexp {
+	expr, ok := value.(string)
+	if !ok {
+		v.fail(s, "must be a regular expression")
+		return nil
+	}
+	re, ok := v.patterns[expr]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(expr); err != nil {
+			v.fail(s, "invalid regular expression: %s", err)
+			return nil
+		}
+		v.patterns[expr] = re
+	}
+	return re
+}
+
+func checkSchemaPattern(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	str, isString := instance.(string)
+	if !isString {
+		return nil
+	}
+	if re := v.pattern(s, value); re != nil && !re.MatchString(str) {
+		return s.violation("%s does not match %s", jsonValueString(str), re)
+	}
+	return nil
+}
+
+// validateItems validates the array items starting at index start against schema
+func (v *schemaValidator) validateItems(s schemaScope, schema interface{}, items []interface{}, start int) []schemaViolation {
+	var violations []schemaViolation
+	for i := start; i < len(items); i++ {
+		violations = append(violations, v.validate(s.child(strconv.Itoa(i)), schema, items[i])...)
+	}
+	return violations
+}
+
+// validateTuple validates every array item against the schema in the same position
+func (v *schemaValidator) validateTuple(s schemaScope, schemas []interface{}, items []interface{}) []schemaViolation {
+	var violations []schemaViolation
+	for i := 0; i < len(schemas) && i < len(items); i++ {
+		index := strconv.Itoa(i)
+		violations = append(violations, v.validate(s.child(index, index), schemas[i], items[i])...)
+	}
+	return violations
+}
+
+func checkSchemaPrefixItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	schemas, ok := value.([]interface{})
+	if !ok {
+		return v.fail(s, "prefixItems must be an array of schemas")
+	}
+	if !isArray {
+		return nil
+	}
+	return v.validateTuple(s, schemas, items)
+}
+
+func checkSchemaItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	if !isArray {
+		return nil
+	}
+	if schemas, ok := value.([]interface{}); ok {
+		// draft-07 tuple validation
+		return v.validateTuple(s, schemas, items)
+	}
+	start := 0
+	if prefix, ok := schema["prefixItems"].([]interface{}); ok {
+		start = len(prefix)
+	}
+	return v.validateItems(s, value, items, start)
+}
+
+func checkSchemaAdditionalItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	tuple, ok := schema["items"].([]interface{})
+	if !isArray || !ok {
+		return nil
+	}
+	return v.validateItems(s, value, items, len(tuple))
+}
+
+func checkSchemaContains(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	if !isArray {
+		return nil
+	}
+	minContains, maxContains := 1, -1
+	if n, ok := schema["minContains"]; ok {
+		if minContains, ok = v.schemaCount(s.sibling("minContains"), n); !ok {
+			return nil
+		}
+	}
+	if n, ok := schema["maxContains"]; ok {
+		if maxContains, ok = v.schemaCount(s.sibling("maxContains"), n); !ok {
+			return nil
+		}
+	}
+	matches := 0
+	for i, item := range items {
+		if v.valid(s.child(strconv.Itoa(i)), value, item) {
+			matches++
+		}
+	}
+	if matches < minContains {
+		return s.violation("%d items match, expected at least %d", matches, minContains)
+	}
+	if maxContains >= 0 && matches > maxContains {
+		return s.violation("%d items match, expected at most %d", matches, maxContains)
+	}
+	return nil
+}
+
+func checkSchemaMaxItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	limit, ok := v.schemaCount(s, value)
+	if isArray && ok && len(items) > limit {
+		return s.violation("has %d items, expected at most %d", len(items), limit)
+	}
+	return nil
+}
+
+func checkSchemaMinItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	limit, ok := v.schemaCount(s, value)
+	if isArray && ok && len(items) < limit {
+		return s.violation("has %d items, expected at least %d", len(items), limit)
+	}
+	return nil
+}
+
+func checkSchemaUniqueItems(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	items, isArray := instance.([]interface{})
+	if !isArray || value != true {
+		return nil
+	}
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return s.violation("items %d and %d are equal", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaMap returns a keyword whose value must be an object
+func (v *schemaValidator) schemaMap(s schemaScope, value interface{}) (map[string]interface{}, bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(s, "must be an object")
+	}
+	return m, ok
+}
+
+func checkSchemaProperties(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	properties, ok := v.schemaMap(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(properties) {
+		if member, ok := object[name]; ok {
+			violations = append(violations, v.validate(s.child(name, name), properties[name], member)...)
+		}
+	}
+	return violations
+}
+
+func checkSchemaPatternProperties(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	patterns, ok := v.schemaMap(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, expr := range sortedMapKeys(patterns) {
+		re := v.pattern(s.keyword(expr), expr)
+		if re == nil {
+			return nil
+		}
+		for _, name := range sortedMapKeys(object) {
+			if re.MatchString(name) {
+				violations = append(violations, v.validate(s.child(name, expr), patterns[expr], object[name])...)
+			}
+		}
+	}
+	return violations
+}
+
+func checkSchemaAdditionalProperties(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	if !isObject {
+		return nil
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	patterns, _ := schema["patternProperties"].(map[string]interface{})
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(object) {
+		if _, ok := properties[name]; ok {
+			continue
+		}
+		matched := false
+		for expr := range patterns {
+			if re := v.pattern(s, expr); re != nil && re.MatchString(name) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if value == false {
+			violations = append(violations, s.child(name).violation("property is not allowed")...)
+			continue
+		}
+		violations = append(violations, v.validate(s.child(name), value, object[name])...)
+	}
+	return violations
+}
+
+func checkSchemaPropertyNames(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	if !isObject {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(object) {
+		for _, violation := range v.validate(s.child(name), value, name) {
+			violation.message = "property name " + violation.message
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+func checkSchemaMaxProperties(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	limit, ok := v.schemaCount(s, value)
+	if isObject && ok && len(object) > limit {
+		return s.violation("has %d properties, expected at most %d", len(object), limit)
+	}
+	return nil
+}
+
+func checkSchemaMinProperties(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	limit, ok := v.schemaCount(s, value)
+	if isObject && ok && len(object) < limit {
+		return s.violation("has %d properties, expected at least %d", len(object), limit)
+	}
+	return nil
+}
+
+// schemaStrings returns a keyword whose value must be an array of strings
+func (v *schemaValidator) schemaStrings(s schemaScope, value interface{}) ([]string, bool) {
+	values, ok := value.([]interface{})
+	if !ok {
+		v.fail(s, "must be an array of strings")
+		return nil, false
+	}
+	strs := make([]string, len(values))
+	for i, value := range values {
+		if strs[i], ok = value.(string); !ok {
+			v.fail(s, "must be an array of strings")
+			return nil, false
+		}
+	}
+	return strs, true
+}
+
+// missingProperties reports the required properties object lacks
+func missingProperties(s schemaScope, object map[string]interface{}, required []string, format string, args ...interface{}) []schemaViolation {
+	var violations []schemaViolation
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, s.violation(format, append([]interface{}{name}, args...)...)...)
+		}
+	}
+	return violations
+}
+
+func checkSchemaRequired(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	required, ok := v.schemaStrings(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	return missingProperties(s, object, required, "missing required property %q")
+}
+
+func checkSchemaDependentRequired(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	dependencies, ok := v.schemaMap(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(dependencies) {
+		if _, ok := object[name]; !ok {
+			continue
+		}
+		required, ok := v.schemaStrings(s.keyword(name), dependencies[name])
+		if !ok {
+			return nil
+		}
+		violations = append(violations, missingProperties(s.keyword(name), object, required, "missing property %q, required by %q", name)...)
+	}
+	return violations
+}
+
+func checkSchemaDependentSchemas(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	dependencies, ok := v.schemaMap(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(dependencies) {
+		if _, ok := object[name]; ok {
+			violations = append(violations, v.validate(s.keyword(name), dependencies[name], instance)...)
+		}
+	}
+	return violations
+}
+
+// checkSchemaDependencies handles the draft-07 keyword that dependentRequired
+// and dependentSchemas replaced
+func checkSchemaDependencies(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	object, isObject := instance.(map[string]interface{})
+	dependencies, ok := v.schemaMap(s, value)
+	if !isObject || !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for _, name := range sortedMapKeys(dependencies) {
+		if _, ok := object[name]; !ok {
+			continue
+		}
+		if _, isArray := dependencies[name].([]interface{}); !isArray {
+			violations = append(violations, v.validate(s.keyword(name), dependencies[name], instance)...)
+			continue
+		}
+		required, ok := v.schemaStrings(s.keyword(name), dependencies[name])
+		if !ok {
+			return nil
+		}
+		violations = append(violations, missingProperties(s.keyword(name), object, required, "missing property %q, required by %q", name)...)
+	}
+	return violations
+}
+
+// schemaList returns a keyword whose value must be a non-empty array of schemas
+func (v *schemaValidator) schemaList(s schemaScope, value interface{}) ([]interface{}, bool) {
+	schemas, ok := value.([]interface{})
+	if !ok || len(schemas) == 0 {
+		v.fail(s, "must be a non-empty array of schemas")
+		return nil, false
+	}
+	return schemas, true
+}
+
+func checkSchemaAllOf(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	schemas, ok := v.schemaList(s, value)
+	if !ok {
+		return nil
+	}
+	var violations []schemaViolation
+	for i, sub := range schemas {
+		violations = append(violations, v.validate(s.keyword(strconv.Itoa(i)), sub, instance)...)
+	}
+	return violations
+}
+
+func checkSchemaAnyOf(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	schemas, ok := v.schemaList(s, value)
+	if !ok {
+		return nil
+	}
+	for i, sub := range schemas {
+		if v.valid(s.keyword(strconv.Itoa(i)), sub, instance) {
+			return nil
+		}
+	}
+	return s.violation("does not match any of the %d schemas", len(schemas))
+}
+
+func checkSchemaOneOf(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	schemas, ok := v.schemaList(s, value)
+	if !ok {
+		return nil
+	}
+	var matches []string
+	for i, sub := range schemas {
+		if v.valid(s.keyword(strconv.Itoa(i)), sub, instance) {
+			matches = append(matches, strconv.Itoa(i))
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return s.violation("does not match any of the %d schemas", len(schemas))
+	case 1:
+		return nil
+	}
+	return s.violation("matches schemas %s, expected exactly one", strings.Join(matches, ", "))
+}
+
+func checkSchemaNot(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	if v.valid(s, value, instance) {
+		return s.violation("must not match the schema")
+	}
+	return nil
+}
+
+func checkSchemaIf(v *schemaValidator, s schemaScope, schema map[string]interface{}, value, instance interface{}) []schemaViolation {
+	branch := "else"
+	if v.valid(s, value, instance) {
+		branch = "then"
+	}
+	sub, ok := schema[branch]
+	if !ok {
+		return nil
+	}
+	return v.validate(s.sibling(branch), sub, instance)
+}
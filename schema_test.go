@@ -0,0 +1,119 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+type person struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Email   string            `json:"email,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	Address map[string]string `json:"address,omitempty"`
+}
+
+func TestJSONMatchesSchema(tx *testing.T) {
+	t := ut.BeginTest(tx, false)
+	defer t.FinishTest()
+
+	t.JSONMatchesSchema("person.json", person{
+		Name:    "Ana",
+		Age:     31,
+		Email:   "ana@example.com",
+		Tags:    []string{"admin", "staff"},
+		Address: map[string]string{"street": "Gran Vía 1", "country": "ES"},
+	})
+	t.JSONMatchesSchema("person.json", []byte(`{"name": "Luis", "age": 40, "work": "Rue de Rivoli"}`))
+}
+
+func TestJSONMatchesSchemaViolations(t *testing.T) {
+	ft, early, _ := MetaTester("TestJSONMatchesSchema", func(tt *ut.TestTools) {
+		tt.JSONMatchesSchema("person.json", []byte(`{
+			"age": -1.5,
+			"email": "nobody",
+			"tags": ["admin", "root", "admin"],
+			"address": {"street": "A very long street name indeed", "zip": true},
+			"phone": "555"
+		}`))
+	})
+	output := ft.Output()
+	if !early || !ft.fail {
+		t.Fatalf("Expected schema violations to fail the test")
+	}
+	for _, line := range []string{
+		"\tJSON does not match schema person.json in 10 places:\n",
+		"\t(root): /required: missing required property \"name\"\n",
+		"\t/address: /properties/address/$ref/required: missing required property \"country\"\n",
+		"\t/address/street: /properties/address/$ref/properties/street/$ref/maxLength: \"A very long street name indeed\" is longer than 20 characters\n",
+		"\t/address/zip: /properties/address/$ref/properties/zip/oneOf: does not match any of the 2 schemas\n",
+		"\t/age: /properties/age/minimum: -1.5 is less than the minimum of 0\n",
+		"\t/age: /properties/age/type: expected integer, got number\n",
+		"\t/email: /properties/email/pattern: \"nobody\" does not match ^[^@]+@[^@]+$\n",
+		"\t/phone: /additionalProperties: property is not allowed\n",
+		"\t/tags: /properties/tags/uniqueItems: items 0 and 2 are equal\n",
+		"\t/tags/1: /properties/tags/items/$ref/enum: \"root\" is not one of [\"admin\",\"staff\",\"guest\"]\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Fatalf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestJSONMatchesSchemaBasic(t *testing.T) {
+	dir := tempTestdata(t)
+
+	schemas := map[string]string{
+		"list.json":   `{"type": "array", "items": {"$ref": "item.json"}, "minItems": 1}`,
+		"item.json":   `{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}`,
+		"cycle.json":  `{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}}`,
+		"remote.json": `{"$ref": "https://example.com/schemas/item.json"}`,
+	}
+	for name, schema := range schemas {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(schema), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ut.JSONMatchesSchema(t, filepath.Join(dir, "list.json"), []map[string]int{{"id": 1}, {"id": 2}})
+
+	for _, test := range []struct {
+		schema string
+		actual interface{}
+	}{
+		{"list.json", []int{}},
+		{"list.json", []map[string]interface{}{{"id": "1"}}},
+		{"cycle.json", 1},
+		{"remote.json", 1},
+		{"missing.json", 1},
+	} {
+		ft := new(fakeT)
+		func() {
+			defer func() { recover() }()
+			ut.JSONMatchesSchema(ft, filepath.Join(dir, test.schema), test.actual)
+		}()
+		if !ft.fail {
+			t.Fatalf("Expected %v not to validate against %s", test.actual, test.schema)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Scrubber normalizes volatile data, such as timestamps or temporary paths,
+// in actual values before they are compared with or stored as golden results
+type Scrubber interface {
+	Scrub(sc *ScrubContext, text string) string
+}
+
+// JSONScrubber is implemented by scrubbers that also operate on the structure of
+// JSON documents rather than only on their strings
+type JSONScrubber interface {
+	ScrubJSON(sc *ScrubContext, doc interface{}) interface{}
+}
+
+// ScrubberFunc adapts a function to the Scrubber interface
+type ScrubberFunc func(sc *ScrubContext, text string) string
+
+// Scrub calls f
+func (f ScrubberFunc) Scrub(sc *ScrubContext, text string) string {
+	return f(sc, text)
+}
+
+// ScrubContext holds the state shared by all scrubbers while scrubbing
+// a single value, so that placeholders are numbered consistently
+type ScrubContext struct {
+	// TempDirs lists the temporary folders created by the test's FileServices
+	TempDirs     []string
+	placeholders map[string]map[string]string
+}
+
+// Placeholder returns <label-N>, where N identifies value among all values
+// scrubbed with the same label. The same value always gets the same placeholder,
+// so identity relationships can still be checked after scrubbing
+func (sc *ScrubContext) Placeholder(label, value string) string {
+	if sc.placeholders == nil {
+		sc.placeholders = make(map[string]map[string]string)
+	}
+	seen, ok := sc.placeholders[label]
+	if !ok {
+		seen = make(map[string]string)
+		sc.placeholders[label] = seen
+	}
+	placeholder, ok := seen[value]
+	if !ok {
+		placeholder = fmt.Sprintf("<%s-%d>", label, len(seen)+1)
+		seen[value] = placeholder
+	}
+	return placeholder
+}
+
+var (
+	uuidExpr     = `(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`
+	rfc3339Expr  = `\b\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})`
+	durationExpr = `\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`
+)
+
+// ScrubRegexp replaces all matches of the regular expression expr with replacement,
+// which can refer to submatches as in regexp.ReplaceAllString
+func ScrubRegexp(expr, replacement string) Scrubber {
+	re := regexp.MustCompile(expr)
+	return ScrubberFunc(func(sc *ScrubContext, text string) string {
+		return re.ReplaceAllString(text, replacement)
+	})
+}
+
+// ScrubRegexpNumbered replaces every distinct match of the regular expression expr
+// with a numbered placeholder such as <label-1>
+func ScrubRegexpNumbered(expr, label string) Scrubber {
+	re := regexp.MustCompile(expr)
+	return ScrubberFunc(func(sc *ScrubContext, text string) string {
+		return re.ReplaceAllStringFunc(text, func(match string) string {
+			return sc.Placeholder(label, match)
+		})
+	})
+}
+
+// ScrubUUIDs replaces UUIDs with <uuid-1>, <uuid-2>...
+func ScrubUUIDs() Scrubber {
+	return ScrubRegexpNumbered(uuidExpr, "uuid")
+}
+
+// ScrubRFC3339 replaces RFC3339 timestamps with <time-1>, <time-2>...
+func ScrubRFC3339() Scrubber {
+	return ScrubRegexpNumbered(rfc3339Expr, "time")
+}
+
+// ScrubDurations replaces durations as printed by time.Duration, such as 1m2.5s, with <duration>
+func ScrubDurations() Scrubber {
+	return ScrubRegexp(durationExpr, "<duration>")
+}
+
+// ScrubHostname replaces the name of the host running the test with <hostname>
+func ScrubHostname() Scrubber {
+	hostname, err := os.Hostname()
+	return ScrubberFunc(func(sc *ScrubContext, text string) string {
+		if err != nil || hostname == "" {
+			return text
+		}
+		return strings.Replace(text, hostname, "<hostname>", -1)
+	})
+}
+
+// ScrubTempDirs replaces the paths of the temporary folders created by the test
+// through FileServices with <tempdir-1>, <tempdir-2>...
+func ScrubTempDirs() Scrubber {
+	return ScrubberFunc(func(sc *ScrubContext, text string) string {
+		for _, dir := range sc.TempDirs {
+			if strings.Contains(text, dir) {
+				text = strings.Replace(text, dir, sc.Placeholder("tempdir", dir), -1)
+			}
+		}
+		return text
+	})
+}
+
+type jsonPointerScrubber struct {
+	pattern     []string
+	replacement interface{}
+}
+
+// ScrubJSONPointer replaces the values found at the given JSON Pointer with replacement
+// in JSON golden results. A * token in the pointer matches any member or element,
+// e.g. /items/*/id. Plain text is not affected
+func ScrubJSONPointer(pointer string, replacement interface{}) Scrubber {
+	pattern, err := parseJSONPointer(pointer)
+	if err != nil {
+		panic(err)
+	}
+	return &jsonPointerScrubber{
+		pattern:     pattern,
+		replacement: replacement,
+	}
+}
+
+func (js *jsonPointerScrubber) Scrub(sc *ScrubContext, text string) string {
+	return text
+}
+
+func (js *jsonPointerScrubber) ScrubJSON(sc *ScrubContext, doc interface{}) interface{} {
+	return walkJSON(doc, nil, func(tokens []string, value interface{}) interface{} {
+		if matchJSONPointer(js.pattern, tokens) {
+			return js.replacement
+		}
+		return value
+	})
+}
+
+// AddScrubber registers scrubbers that will normalize actual values before they are
+// compared with or stored as golden results by EqualsTextFile, EqualsFile, EqualsKey,
+// JSONEqualsFile and JSONBytesEqualsFile
+func (tt *TestTools) AddScrubber(scrubbers ...Scrubber) {
+	tt.scrubbers = append(tt.scrubbers, scrubbers...)
+}
+
+// newScrubContext returns a fresh context to scrub one value
+func (tt *TestTools) newScrubContext() *ScrubContext {
+	sc := new(ScrubContext)
+	for _, s := range tt.services {
+		if td, ok := s.(*TempDir); ok {
+			sc.TempDirs = append(sc.TempDirs, td.tempFileDir)
+			if resolved, err := filepath.EvalSymlinks(td.tempFileDir); err == nil && resolved != td.tempFileDir {
+				sc.TempDirs = append(sc.TempDirs, resolved)
+			}
+		}
+	}
+	// replace nested folders before their parents
+	sort.Slice(sc.TempDirs, func(i, j int) bool {
+		return len(sc.TempDirs[i]) > len(sc.TempDirs[j])
+	})
+	return sc
+}
+
+// scrubText applies all registered scrubbers to text
+func (tt *TestTools) scrubText(text string) string {
+	sc := tt.newScrubContext()
+	for _, s := range tt.scrubbers {
+		text = s.Scrub(sc, text)
+	}
+	return text
+}
+
+// scrubJSON applies all registered scrubbers to the strings and member names
+// of a JSON document, as well as JSON scrubbers to its structure
+func (tt *TestTools) scrubJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	sc := tt.newScrubContext()
+	scrub := func(text string) string {
+		for _, s := range tt.scrubbers {
+			text = s.Scrub(sc, text)
+		}
+		return text
+	}
+	doc = walkJSON(doc, nil, func(tokens []string, value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return scrub(v)
+		case map[string]interface{}:
+			scrubbed := make(map[string]interface{}, len(v))
+			for key, member := range v {
+				scrubbed[scrub(key)] = member
+			}
+			return scrubbed
+		}
+		return value
+	})
+	for _, s := range tt.scrubbers {
+		if js, ok := s.(JSONScrubber); ok {
+			doc = js.ScrubJSON(sc, doc)
+		}
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestScrubbers(t *testing.T) {
+	dir := tempTestdata(t)
+
+	expectedText := "<uuid-1> created at <time-1> in <tempdir-1>/file\n<uuid-2> read <uuid-1> in <duration>\n"
+	err := ioutil.WriteFile(filepath.Join(dir, "log.txt"), []byte(expectedText), 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"id": "<uuid-1>", "parent": "<uuid-1>", "etag": "xyz", "items": [{"n": 1, "at": "<time-1>"}]}`
+	err = ioutil.WriteFile(filepath.Join(dir, "doc.json"), []byte(expectedJSON), 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ft, early, _ := MetaTester("Scrubbers", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.AddScrubber(ut.ScrubUUIDs(), ut.ScrubRFC3339(), ut.ScrubDurations(), ut.ScrubTempDirs(),
+			ut.ScrubJSONPointer("/etag", "xyz"))
+		tempDir := ut.NewFileServices(tt).NewTempDir()
+
+		log := fmt.Sprintf("%s created at %s in %s/file\n%s read %s in %s\n",
+			"0b7cbfa6-5f4a-4cf4-a0b4-9a5c4b5d9f1e", "2020-01-02T03:04:05.123Z", tempDir,
+			"7a3c9e6b-32cb-4f70-8d2e-4a3f8e2d3c10", "0b7cbfa6-5f4a-4cf4-a0b4-9a5c4b5d9f1e", "1m2.5s")
+		tt.EqualsTextFile("log.txt", log)
+
+		doc := map[string]interface{}{
+			"id":     "e2a1b7de-1111-4a4a-8b8b-123456789abc",
+			"parent": "e2a1b7de-1111-4a4a-8b8b-123456789abc",
+			"etag":   "W/12345",
+			"items":  []interface{}{map[string]interface{}{"n": 1, "at": "2021-05-06T07:08:09+02:00"}},
+		}
+		tt.EqualsFile("doc.json", doc)
+		tt.JSONEqualsFile("doc.json", doc)
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected scrubbed values to match, got early=%v, failed=%v", early, ft.fail)
+	}
+
+	ft, early, _ = MetaTester("Scrubbers", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.AddScrubber(ut.ScrubUUIDs())
+		doc := map[string]interface{}{
+			"id":     "e2a1b7de-1111-4a4a-8b8b-123456789abc",
+			"parent": "00000000-1111-4a4a-8b8b-123456789abc",
+			"etag":   "xyz",
+			"items":  []interface{}{map[string]interface{}{"n": 1, "at": "<time-1>"}},
+		}
+		tt.EqualsFile("doc.json", doc)
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected different UUIDs to get different placeholders")
+	}
+}
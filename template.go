@@ -0,0 +1,168 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders that golden text files can contain to match variable
+// segments of the actual text:
+//
+//	{{any}}       matches any sequence of characters within a line
+//	{{int}}       matches an optionally signed integer
+//	{{re:EXPR}}   matches the regular expression EXPR
+//
+// A golden line containing placeholders matches an actual line if the whole
+// line matches, taking the rest of the golden line literally.
+var placeholderExprs = map[string]string{
+	"any": `.*`,
+	"int": `[-+]?\d+`,
+}
+
+// templateLine is a golden text line compiled to match actual lines
+type templateLine struct {
+	text string
+	re   *regexp.Regexp
+}
+
+func (tl *templateLine) matches(line string) bool {
+	if tl.re == nil {
+		return tl.text == line
+	}
+	return tl.re.MatchString(line)
+}
+
+// hasPlaceholders tells whether golden text contains any placeholder
+func hasPlaceholders(text string) bool {
+	if !strings.Contains(text, "{{") {
+		return false
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if compileTemplateLine(line).re != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compileTemplateLine converts a golden line into a regular expression if it
+// contains placeholders. Invalid placeholders are taken literally
+func compileTemplateLine(line string) *templateLine {
+	tl := &templateLine{text: line}
+	var expr strings.Builder
+	expr.WriteString("^")
+	found := false
+	rest := line
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		placeholder, length := parsePlaceholder(rest[start:])
+		if placeholder == "" {
+			expr.WriteString(regexp.QuoteMeta(rest[:start+2]))
+			rest = rest[start+2:]
+			continue
+		}
+		expr.WriteString(regexp.QuoteMeta(rest[:start]))
+		expr.WriteString("(?:" + placeholder + ")")
+		rest = rest[start+length:]
+		found = true
+	}
+	if !found {
+		return tl
+	}
+	expr.WriteString(regexp.QuoteMeta(rest))
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err == nil {
+		tl.re = re
+	}
+	return tl
+}
+
+// parsePlaceholder parses the placeholder at the start of s, returning the
+// regular expression it stands for and its length, or "" if there is none.
+// The closing braces of {{re:...}} are the first }} not followed by another },
+// so that expressions like {{re:[a-f0-9]{8}}} work as expected
+func parsePlaceholder(s string) (expr string, length int) {
+	end := strings.Index(s, "}}")
+	if end < 0 {
+		return "", 0
+	}
+	for end+2 < len(s) && s[end+2] == '}' {
+		end++
+	}
+	name := s[2:end]
+	if strings.HasPrefix(name, "re:") {
+		if _, err := regexp.Compile(name[3:]); err != nil {
+			return "", 0
+		}
+		return name[3:], end + 2
+	}
+	if strings.Contains(name, "}") {
+		end = strings.Index(s, "}}")
+		name = s[2:end]
+	}
+	expr, ok := placeholderExprs[name]
+	if !ok {
+		return "", 0
+	}
+	return expr, end + 2
+}
+
+// matchTemplate matches actual text against golden text containing placeholders.
+// It returns whether they match, along with a normalized copy of actual in
+// which every line that matches its aligned golden line is replaced by the
+// golden line itself, so diffs only show the real differences and regenerated
+// golden files keep their placeholders
+func matchTemplate(expected, actual string) (normalized string, ok bool) {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+	template := make([]*templateLine, len(expectedLines))
+	for i, line := range expectedLines {
+		template[i] = compileTemplateLine(line)
+	}
+
+	// fast path: lines match one to one
+	if len(template) == len(actualLines) {
+		ok = true
+		for i, tl := range template {
+			if !tl.matches(actualLines[i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return expected, true
+		}
+	}
+
+	// align lines as diffs do, keeping together lines that match
+	normalizedLines := make([]string, len(actualLines))
+	copy(normalizedLines, actualLines)
+	for _, op := range myersAlign(len(template), len(actualLines), func(i, j int) bool {
+		return template[i].matches(actualLines[j])
+	}) {
+		if op.kind == ' ' {
+			normalizedLines[op.b] = template[op.a].text
+		}
+	}
+	return strings.Join(normalizedLines, "\n"), false
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestTextFilePlaceholders(t *testing.T) {
+	dir := tempTestdata(t)
+
+	path := filepath.Join(dir, "out.txt")
+	golden := "started at {{any}}\ncount={{int}}\nid={{re:[a-f0-9]{8}}}\nliteral {{unknown}}\ndone"
+	if err := ioutil.WriteFile(path, []byte(golden), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		actual string
+		fail   bool
+	}{
+		{"started at 10:00\ncount=-42\nid=0badcafe\nliteral {{unknown}}\ndone", false},
+		{"started at \ncount=7\nid=deadbeef\nliteral {{unknown}}\ndone", false},
+		{"started at 10:00\ncount=x\nid=0badcafe\nliteral {{unknown}}\ndone", true},
+		{"started at 10:00\ncount=1\nid=0badcafe\nliteral {{unknown}}\nextra\ndone", true},
+		{"started at 10:00\ncount=1\nid=0BADCAFE\nliteral {{unknown}}\ndone", true},
+	}
+	for i, test := range tests {
+		ft, _, _ := MetaTester("TextFilePlaceholders", func(tt *ut.TestTools) {
+			tt.TestdataDir = dir
+			tt.EqualsTextFile("out.txt", test.actual)
+		})
+		if ft.fail != test.fail {
+			t.Fatalf("#%d: expected failed=%v, got %v", i, test.fail, ft.fail)
+		}
+	}
+
+	os.Setenv(ut.UpdateEnv, "out.txt")
+	defer os.Unsetenv(ut.UpdateEnv)
+	MetaTester("TextFilePlaceholders", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("out.txt", "started at 11:00\ncount=abc\nid=12345678\nliteral {{unknown}}\nnew line\ndone")
+	})
+	regenerated, err := ioutil.ReadFile(path)
+	ut.Ok(t, err)
+	ut.Equals(t, "started at {{any}}\ncount=abc\nid={{re:[a-f0-9]{8}}}\nliteral {{unknown}}\nnew line\ndone", string(regenerated))
+}
+
+func TestTextFilePlaceholdersLarge(t *testing.T) {
+	dir := tempTestdata(t)
+
+	// aligning these line by line against each other would take
+	// billions of comparisons, while they only differ in one line
+	const n = 50000
+	golden := strings.Repeat("line {{int}}\n", n)
+	actual := strings.Repeat("line 1\n", n/2) + "changed\n" + strings.Repeat("line 1\n", n/2-1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "out.txt"), []byte(golden), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	ft, _, _ := MetaTester("TextFilePlaceholdersLarge", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("out.txt", actual)
+	})
+	if output := ft.Output(); !ft.fail || !strings.Contains(output, "-line {{int}}\n") || !strings.Contains(output, "+changed\n") {
+		t.Fatalf("Expected the changed line to be reported, got:\n%.2000s", output)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import (
+	"os"
+	"regexp"
+)
+
+// UpdateEnv is the environment variable holding a regular expression that
+// selects the golden results to regenerate. The expression is matched against
+// TestName/golden, where golden is a testdata file name or a results.json key
+const UpdateEnv = "UT_UPDATE"
+
+// updatePattern returns the expression selecting which golden results to
+// regenerate, or nil if update mode is off
+func updatePattern() (*regexp.Regexp, error) {
+	pattern := os.Getenv(UpdateEnv)
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// shouldGenerate tells whether the golden result identified by name has to be
+// (re)generated rather than verified
+func (tt *TestTools) shouldGenerate(name string) bool {
+	if tt.generateResults {
+		return true
+	}
+	return tt.update != nil && tt.update.MatchString(tt.T.Name()+"/"+name)
+}
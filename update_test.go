@@ -0,0 +1,111 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/epiclabs-io/ut"
+)
+
+func TestUpdatePattern(t *testing.T) {
+	dir := tempTestdata(t)
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("old"), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Setenv(ut.UpdateEnv, `^UpdatePattern/(a\.txt|sum)$`)
+	defer os.Unsetenv(ut.UpdateEnv)
+
+	ft, early, _ := MetaTester("UpdatePattern", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("a.txt", "new") // regenerated
+		tt.EqualsTextFile("b.txt", "old") // verified
+		tt.EqualsKey("sum", 12)           // regenerated
+	})
+	if early || ft.fail {
+		t.Fatalf("Expected update mode to let the test pass, got early=%v, failed=%v", early, ft.fail)
+	}
+
+	a, _ := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(a) != "new" {
+		t.Fatalf("Expected a.txt to be regenerated, got %q", string(a))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results.json")); err != nil {
+		t.Fatalf("Expected results.json to be written: %s", err)
+	}
+
+	ft, early, _ = MetaTester("UpdatePattern", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.EqualsTextFile("b.txt", "new") // does not match the pattern
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected goldens not matching the pattern to be verified")
+	}
+}
+
+func TestGenerateMergesResults(t *testing.T) {
+	dir := tempTestdata(t)
+
+	path := filepath.Join(dir, "results.json")
+	if err := ioutil.WriteFile(path, []byte(`{"b": 2, "a": 1}`), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	ft := &fakeT{name: "GenerateMergesResults"}
+	tt := ut.ToolsBeginTest(ft, true)
+	tt.TestdataDir = dir
+	tt.LoadResults()
+	tt.EqualsKey("c", 3)
+	tt.FinishTest()
+
+	resultBytes, err := ioutil.ReadFile(path)
+	ut.Ok(t, err)
+	ut.Equals(t, "{\n\t\"a\": 1,\n\t\"b\": 2,\n\t\"c\": 3\n}", string(resultBytes))
+}
+
+func TestFailingTestKeepsResults(t *testing.T) {
+	dir := tempTestdata(t)
+
+	path := filepath.Join(dir, "results.json")
+	if err := ioutil.WriteFile(path, []byte(`{"a": 1}`), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(ut.UpdateEnv, `^FailingTestKeepsResults/a$`)
+	defer os.Unsetenv(ut.UpdateEnv)
+
+	ft, early, _ := MetaTester("FailingTestKeepsResults", func(tt *ut.TestTools) {
+		tt.TestdataDir = dir
+		tt.LoadResults()
+		tt.EqualsKey("a", 2)
+		tt.Assert(false, "failing after regenerating a result")
+	})
+	if !early || !ft.fail {
+		t.Fatalf("Expected the test to fail")
+	}
+
+	resultBytes, err := ioutil.ReadFile(path)
+	ut.Ok(t, err)
+	ut.Equals(t, `{"a": 1}`, string(resultBytes))
+}
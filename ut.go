@@ -24,11 +24,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
-	"strings"
 	"sync"
-
-	"github.com/epiclabs-io/diff3"
 )
 
 // Service is an interface to define a test service that needs
@@ -59,54 +57,94 @@ type results map[string]json.RawMessage
 // useful testing methods
 type TestTools struct {
 	T
-	W               sync.WaitGroup
-	err             chan error
-	SubTest         SubTest
-	TestdataDir     string
-	generateResults bool
-	Results         results
-	services        []Service
+	W                 sync.WaitGroup
+	err               chan error
+	SubTest           SubTest
+	TestdataDir       string
+	SharedTestdataDir string
+	Codec             Codec
+	generateResults   bool
+	update            *regexp.Regexp
+	mu                sync.Mutex // guards the bookkeeping below, which checks run with Go may update
+	resultsDirty      bool
+	touchedFiles      map[string]bool
+	touchedKeys       map[string]bool
+	receivedKeys      results
+	Results           results
+	services          []Service
+	scrubbers         []Scrubber
+	comparers         map[reflect.Type]reflect.Value
+	parent            *TestTools
 }
 
 // ToolsBeginTest takes a *testing.T and returns a replacement
 // TestTools. Don't forget to defer t.FinishTest() to ensure
-// cleanup
+// cleanup.
+// Golden results can also be regenerated selectively without touching
+// the code by means of the UT_UPDATE environment variable
 func ToolsBeginTest(t T, generateResults bool) *TestTools {
+	helperOf(t)()
 	_, file, _, _ := runtime.Caller(2)
+	update, err := updatePattern()
+	if err != nil {
+		t.Fatalf("Invalid update pattern: %s", err)
+	}
 	tt := &TestTools{
-		err:             make(chan error, 20),
-		T:               t,
-		TestdataDir:     filepath.Join(filepath.Dir(file), "testdata", t.Name()),
-		generateResults: GENERATE_RESULTS || generateResults,
+		err:               make(chan error, 20),
+		T:                 t,
+		TestdataDir:       filepath.Join(filepath.Dir(file), "testdata", t.Name()),
+		SharedTestdataDir: filepath.Join(filepath.Dir(file), "testdata"),
+		generateResults:   GENERATE_RESULTS || generateResults,
+		update:            update,
 	}
-	tt.loadResults()
+	tt.LoadResults()
 	return tt
 }
 
-// loadResults loads key-value results from the corresponding
-// test folder /results.json file
-func (tt *TestTools) loadResults() {
-	if tt.generateResults {
+// LoadResults loads key-value results from the corresponding
+// test folder /results.json file. Existing results are also loaded when
+// generating them, so keys not visited during the run are preserved
+func (tt *TestTools) LoadResults() {
+	tt.resultsDirty = false
+	path := filepath.Join(tt.TestdataDir, "results.json")
+	resultBytes, err := ioutil.ReadFile(path)
+	if err != nil {
 		tt.Results = make(results)
-	} else {
-		path := filepath.Join(tt.TestdataDir, "results.json")
-		resultBytes, err := ioutil.ReadFile(path)
-		if err != nil {
-			tt.Results = make(results)
-			return
-		}
-		err = json.Unmarshal(resultBytes, &tt.Results)
-		if err != nil {
-			tt.Results = make(results)
-			return
-		}
+		return
 	}
+	err = json.Unmarshal(resultBytes, &tt.Results)
+	if err != nil {
+		tt.Results = make(results)
+		return
+	}
+}
+
+// result returns the stored value of a results.json key
+func (tt *TestTools) result(key string) (json.RawMessage, bool) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	data, ok := tt.Results[key]
+	return data, ok
+}
+
+// setResult stores the value of a results.json key, to be saved when the test finishes
+func (tt *TestTools) setResult(key string, data json.RawMessage) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.Results[key] = data
+	tt.resultsDirty = true
+}
+
+// in returns the Internal test functions bound to the test
+func (tt *TestTools) in() *internal {
+	return Internal.For(tt.T)
 }
 
 // Error stops the test with the given error
 func (tt *TestTools) Error(err error) {
+	helperOf(tt.T)()
 	if tt.SubTest != nil {
-		fmt.Printf("Failed subtest: %s\n", tt.SubTest.String())
+		tt.T.Logf("Failed subtest: %s", tt.SubTest.String())
 	}
 	select {
 	case tt.err <- err:
@@ -118,32 +156,50 @@ func (tt *TestTools) Error(err error) {
 
 // Assert verifies if the condition is true. If not, it fails the test
 func (tt *TestTools) Assert(condition bool, msg string, v ...interface{}) {
-	if Internal.NotAssert(0, condition, msg, v...) {
+	helperOf(tt.T)()
+	if tt.in().NotAssert(0, condition, msg, v...) {
 		tt.Error(fmt.Errorf("Assertion failed: %s", msg))
 	}
 }
 
-// Ok checks if there is no error. Otherwise it fails the test
-func (tt *TestTools) Ok(err error) {
-	if Internal.NotOk(0, err) {
+// Ok checks if there is no error. Otherwise it fails the test.
+// Like the rest of checks, it takes an optional message and arguments
+// that are added to the failure report
+func (tt *TestTools) Ok(err error, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	if tt.in().WithMessage(msgAndArgs...).NotOk(0, err) {
 		tt.Error(err)
 	}
 }
 
-// Equals tests if both objects are "deeply equal", otherwise it fails the test
-func (tt *TestTools) Equals(expected, actual interface{}) {
-	if Internal.NotEquals(0, expected, actual) {
+// Equals tests if both objects are "deeply equal", otherwise it fails the test.
+// Options such as IgnoreFields, UseEqualMethods or SortSlices, as well as the
+// comparers added with AddComparer, relax the comparison. Options go before
+// the optional message and arguments
+func (tt *TestTools) Equals(expected, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if tt.in().WithMessage(msgAndArgs...).notEqualsWith(0, expected, actual, tt.options(opts)) {
 		tt.Error(errors.New("Expressions don't match"))
 	}
 }
 
-func (tt *TestTools) equalsJSONBytes(callDepth int, name string, actual interface{}, read func() []byte, write func(data []byte)) {
-	if tt.generateResults {
-		actualBytes, err := json.MarshalIndent(actual, "", "\t")
-		if err != nil {
-			tt.Fatalf("Cannot marshal actual value to json to store as result: %s", err)
-		}
-		write(json.RawMessage(actualBytes))
+func (tt *TestTools) equalsEncoded(in *internal, callDepth int, name string, generate bool, o *options, actual interface{}, read func() []byte, write, receive func(data []byte)) {
+	helperOf(tt.T)()
+	codec := o.codec
+	if _, isJSON := codec.(jsonCodec); isJSON && len(tt.scrubbers) > 0 {
+		tt.equalsScrubbedJSON(in, callDepth+1, name, generate, o, actual, read, write, receive)
+		return
+	}
+	actualBytes, err := codec.Marshal(actual)
+	if err != nil {
+		tt.Fatalf("Cannot marshal actual value to store as result: %s", err)
+	}
+	if len(tt.scrubbers) > 0 {
+		actualBytes = []byte(tt.scrubText(string(actualBytes)))
+	}
+	if generate {
+		write(actualBytes)
 		return
 	}
 
@@ -153,35 +209,102 @@ func (tt *TestTools) equalsJSONBytes(callDepth int, name string, actual interfac
 		actual = actualValue.Interface()
 	}
 
+	expectedBytes := read()
 	expectedValuePtr := reflect.New(actualValue.Type())
-	err := json.Unmarshal(read(), expectedValuePtr.Interface())
+	err = codec.Unmarshal(expectedBytes, expectedValuePtr.Interface())
+	if err == ErrUnmarshalNotSupported || err == nil && len(tt.scrubbers) > 0 {
+		if in.NotEncodedEquals(callDepth+1, codec, expectedBytes, actualBytes) {
+			receive(actualBytes)
+			tt.Error(fmt.Errorf("Expressions don't match. Check file '%s' in testdata/%s or key '%s' in testdata/%s/results.json", name, tt.T.Name(), name, tt.T.Name()))
+		}
+		return
+	}
 	if err != nil {
 		tt.Fatalf("Cannot unmarshal result value in '%s'", name)
 	}
 
 	expected := expectedValuePtr.Elem().Interface()
-	if Internal.NotEquals(callDepth+1, expected, actual) {
+	if in.notEqualsWith(callDepth+1, expected, actual, o) {
+		receive(actualBytes)
 		tt.Error(fmt.Errorf("Expressions don't match. Check file '%s' in testdata/%s or key '%s' in testdata/%s/results.json", name, tt.T.Name(), name, tt.T.Name()))
 	}
 
 	return
 }
 
-func (tt *TestTools) equalsString(callDepth int, name string, actual string, read func() string, write func(data string)) {
-	if tt.generateResults {
+// equalsScrubbedJSON compares the scrubbed JSON version of actual with
+// the stored JSON. Both are decoded into actual's type so equality options
+// apply, unless scrubbed values no longer fit it, in which case they are
+// compared as JSON
+func (tt *TestTools) equalsScrubbedJSON(in *internal, callDepth int, name string, generate bool, o *options, actual interface{}, read func() []byte, write, receive func(data []byte)) {
+	helperOf(tt.T)()
+	actualBytes, err := json.Marshal(actual)
+	if err != nil {
+		tt.Fatalf("Cannot marshal actual value to json: %s", err)
+	}
+	actualBytes, err = tt.scrubJSON(actualBytes)
+	if err != nil {
+		tt.Fatalf("Cannot scrub actual value: %s", err)
+	}
+	if generate {
+		write(Internal.JSONPretty(actualBytes))
+		return
+	}
+
+	expectedBytes := read()
+	var differ bool
+	if expected, scrubbed, ok := decodeScrubbed(reflect.TypeOf(actual), expectedBytes, actualBytes); ok {
+		differ = in.notEqualsWith(callDepth+1, expected, scrubbed, o)
+	} else if o.equality.relaxed() {
+		tt.Fatalf("Cannot apply equality options or comparers to '%s': scrubbed values do not fit %T", name, actual)
+	} else {
+		differ = in.NotJSONEquals(callDepth+1, expectedBytes, actualBytes, FloatEpsilon(o.json.epsilon))
+	}
+	if differ {
+		receive(Internal.JSONPretty(actualBytes))
+		tt.Error(fmt.Errorf("Expressions don't match. Check file '%s' in testdata/%s or key '%s' in testdata/%s/results.json", name, tt.T.Name(), name, tt.T.Name()))
+	}
+}
+
+// decodeScrubbed decodes the expected and scrubbed actual JSON into values of
+// the given type, or returns false if either does not fit it
+func decodeScrubbed(typ reflect.Type, expectedBytes, actualBytes []byte) (expected, actual interface{}, ok bool) {
+	if typ == nil {
+		return nil, nil, false
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	expectedPtr, actualPtr := reflect.New(typ), reflect.New(typ)
+	if json.Unmarshal(expectedBytes, expectedPtr.Interface()) != nil || json.Unmarshal(actualBytes, actualPtr.Interface()) != nil {
+		return nil, nil, false
+	}
+	return expectedPtr.Elem().Interface(), actualPtr.Elem().Interface(), true
+}
+
+func (tt *TestTools) equalsString(in *internal, callDepth int, name string, generate bool, actual string, read func() string, write, receive func(data string)) {
+	helperOf(tt.T)()
+	if len(tt.scrubbers) > 0 {
+		actual = tt.scrubText(actual)
+	}
+	if generate {
 		write(actual)
 		return
 	}
 
 	expected := read()
-	if Internal.NotEquals(callDepth+1, expected, actual) {
-		r, err := diff3.Merge(strings.NewReader(expected), strings.NewReader(""), strings.NewReader(actual), true, "EXPECTED", "ACTUAL")
-		if err == nil && r.Conflicts {
-			diff, err := ioutil.ReadAll(r.Result)
-			if err == nil {
-				fmt.Printf("Diff:\n%s\n", string(diff))
-			}
+	if hasPlaceholders(expected) {
+		normalized, ok := matchTemplate(expected, actual)
+		if ok {
+			return
+		}
+		actual = normalized
+	}
+	if in.NotEquals(callDepth+1, expected, actual) {
+		if diff := textDiff(expected, actual); diff != "" {
+			tt.T.Logf("Diff:\n%s", diff)
 		}
+		receive(actual)
 		tt.Error(fmt.Errorf("Expressions don't match. Check file '%s' in testdata/%s", name, tt.T.Name()))
 	}
 
@@ -189,76 +312,150 @@ func (tt *TestTools) equalsString(callDepth int, name string, actual string, rea
 }
 
 // EqualsKey verifies if the passed "actual" value is equal to the value in the
-// given key of the current test's results.json
-func (tt *TestTools) EqualsKey(key string, actual interface{}) {
+// given key of the current test's results.json. Values encoded with a codec
+// other than JSON are stored as JSON strings. FloatEpsilon tolerates small
+// differences in floats, even in nested fields. Options go before the optional
+// message and arguments
+func (tt *TestTools) EqualsKey(key string, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
 	if tt.Results == nil {
 		tt.Fatalf("To use EqualsKey(), call LoadResults() first")
 	}
-	tt.equalsJSONBytes(0, fmt.Sprintf("key:%s", key), actual, func() []byte {
-		expectedValueBytes, ok := tt.Results[key]
+	tt.touchKey(key)
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	o := tt.options(opts)
+	_, isJSON := o.codec.(jsonCodec)
+	// toJSON converts encoded values to what is stored in results.json
+	toJSON := func(data []byte) []byte {
+		if isJSON {
+			return data
+		}
+		jsonBytes, _ := json.Marshal(string(data))
+		return jsonBytes
+	}
+	tt.equalsEncoded(tt.in().WithMessage(msgAndArgs...).WithMessage(o.message), 0, fmt.Sprintf("key:%s", key), tt.shouldGenerate(key), o, actual, func() []byte {
+		helperOf(tt.T)()
+		expectedValueBytes, ok := tt.result(key)
 		if !ok {
 			tt.Fatalf("Cannot find result key '%s'", key)
 		}
-		return expectedValueBytes
+		if isJSON {
+			return expectedValueBytes
+		}
+		var encoded string
+		if err := json.Unmarshal(expectedValueBytes, &encoded); err != nil {
+			tt.Fatalf("Cannot decode result key '%s': %s", key, err)
+		}
+		return []byte(encoded)
 
 	}, func(data []byte) {
-		tt.Results[key] = data
+		tt.setResult(key, toJSON(data))
+	}, func(data []byte) {
+		tt.receiveKey(key, toJSON(data))
 	})
 }
 
 // EqualsTextFile checks if the passed "actual" value is equivalent
 // to the text contained in the indicated file in the current test's
-// testadata folder
-func (tt *TestTools) EqualsTextFile(file string, actual string) {
+// testadata folder. The file can contain placeholders such as {{any}},
+// {{int}} or {{re:[a-f0-9]{8}}} to match variable parts of the text.
+// These are preserved when regenerating lines that still match them
+func (tt *TestTools) EqualsTextFile(file string, actual string, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	tt.touchFile(file)
 	path := filepath.Join(tt.TestdataDir, file)
-	tt.equalsString(0, file, actual, func() string {
+	tt.equalsString(tt.in().WithMessage(msgAndArgs...), 0, file, tt.shouldGenerate(file), actual, func() string {
+		helperOf(tt.T)()
 		expectedValueBytes, err := ioutil.ReadFile(path)
 		if err != nil {
-			tt.Fatalf("Cannot open test result file %s: %s", err)
+			tt.Fatalf("Cannot open test result file %s: %s", path, err)
 		}
 		return string(expectedValueBytes)
 
 	}, func(data string) {
+		helperOf(tt.T)()
+		if existing, err := ioutil.ReadFile(path); err == nil && hasPlaceholders(string(existing)) {
+			data, _ = matchTemplate(string(existing), data)
+		}
 		CreateDirectory(tt.TestdataDir)
 		err := ioutil.WriteFile(path, []byte(data), 0660)
 		if err != nil {
 			tt.Fatalf("Cannot write test result file %s : %s", path, err)
 		}
+	}, func(data string) {
+		tt.writeReceived(path, []byte(data))
 	})
+	removeReceived(path)
 }
 
 // EqualsFile checks if the passed "actual" value is equivalent
-// to its JSON version contained in the indicated file in the current test's
-// testadata folder
-func (tt *TestTools) EqualsFile(file string, actual interface{}) {
+// to its encoded version contained in the indicated file in the current test's
+// testadata folder. Values are encoded as JSON unless another codec is set
+// with WithCodec or in the Codec field. The extension of codecs other than
+// JSON is appended to file names without one. See EqualsKey for the rest of
+// options and the optional message
+func (tt *TestTools) EqualsFile(file string, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	o := tt.options(opts)
+	file = goldenFileName(file, o.codec)
+	tt.touchFile(file)
 	path := filepath.Join(tt.TestdataDir, file)
-	tt.equalsJSONBytes(0, file, actual, func() []byte {
+	tt.equalsEncoded(tt.in().WithMessage(msgAndArgs...).WithMessage(o.message), 0, file, tt.shouldGenerate(file), o, actual, func() []byte {
+		helperOf(tt.T)()
 		expectedValueBytes, err := ioutil.ReadFile(path)
 		if err != nil {
-			tt.Fatalf("Cannot open test result file %s: %s", err)
+			tt.Fatalf("Cannot open test result file %s: %s", path, err)
 		}
 		return expectedValueBytes
 
 	}, func(data []byte) {
+		helperOf(tt.T)()
 		CreateDirectory(tt.TestdataDir)
 		err := ioutil.WriteFile(path, data, 0660)
 		if err != nil {
 			tt.Fatalf("Cannot write test result file %s : %s", path, err)
 		}
+	}, func(data []byte) {
+		tt.writeReceived(path, data)
 	})
+	removeReceived(path)
 }
 
-//JSONEquals checks if the passed values are JSON-equal, comparing values
+// JSONEquals checks if the passed values are JSON-equal, comparing values
 // taking into account keys can be in different order, etc.
-func (tt *TestTools) JSONEquals(expected, actual []byte) {
-	if Internal.NotJSONEquals(0, expected, actual) {
+// Options such as IgnorePaths or UnorderedArrays relax the comparison.
+// They go before the optional message and arguments
+func (tt *TestTools) JSONEquals(expected, actual []byte, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if tt.in().WithMessage(msgAndArgs...).NotJSONEquals(0, expected, actual, opts...) {
+		tt.Error(errors.New("JSONs don't match"))
+	}
+}
+
+// JSONContains checks if the actual JSON contains the expected one,
+// that is, if they are JSON-equal once its extra object members are ignored
+func (tt *TestTools) JSONContains(expected, actual []byte, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	if tt.in().WithMessage(msgAndArgs...).NotJSONEquals(0, expected, actual, append(opts, JSONSubset())...) {
 		tt.Error(errors.New("JSONs don't match"))
 	}
 }
 
-func (tt *TestTools) jsonEqualsFile(callDepth int, file string, actual []byte) {
+func (tt *TestTools) jsonEqualsFile(in *internal, callDepth int, file string, actual []byte, opts []Option) {
+	helperOf(tt.T)()
+	tt.touchFile(file)
+	if len(tt.scrubbers) > 0 {
+		var err error
+		actual, err = tt.scrubJSON(actual)
+		if err != nil {
+			tt.Fatalf("Cannot scrub actual JSON: %s", err)
+		}
+	}
 	path := filepath.Join(tt.TestdataDir, file)
-	if tt.generateResults {
+	if tt.shouldGenerate(file) {
 		CreateDirectory(tt.TestdataDir)
 		err := ioutil.WriteFile(path, Internal.JSONPretty(actual), 0660)
 		if err != nil {
@@ -273,33 +470,41 @@ func (tt *TestTools) jsonEqualsFile(callDepth int, file string, actual []byte) {
 		if err != nil {
 			tt.Fatalf("Cannot read test result file %s : %s", path, err)
 		}
-		if Internal.NotJSONEquals(callDepth+1, expected, actual) {
+		if in.NotJSONEquals(callDepth+1, expected, actual, opts...) {
+			tt.writeReceived(path, Internal.JSONPretty(actual))
 			tt.Error(fmt.Errorf("JSONs don't match. Test result file: %s", path))
 		}
 	}
+	removeReceived(path)
 }
 
 // JSONBytesEqualsFile performs a JSON comparison of the provided JSON bytes
-// with the JSON contained in the referenced file
-func (tt *TestTools) JSONBytesEqualsFile(file string, actual []byte) {
-	tt.jsonEqualsFile(0, file, actual)
+// with the JSON contained in the referenced file, see JSONEquals for options
+func (tt *TestTools) JSONBytesEqualsFile(file string, actual []byte, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	tt.jsonEqualsFile(tt.in().WithMessage(msgAndArgs...), 0, file, actual, opts)
 }
 
 // JSONEqualsFile performs a JSON comparison of the given object
-// with the JSON contained in the referenced file
-func (tt *TestTools) JSONEqualsFile(file string, actual interface{}) {
+// with the JSON contained in the referenced file, see JSONEquals for options
+func (tt *TestTools) JSONEqualsFile(file string, actual interface{}, optsAndMsg ...interface{}) {
+	helperOf(tt.T)()
 	actualBytes, err := json.Marshal(actual)
 	if err != nil {
 		tt.Fatalf("Cannot marshal 'actual' to JSON: %s", err)
 	}
-	tt.jsonEqualsFile(0, file, actualBytes)
+	opts, msgAndArgs := splitOptions(optsAndMsg)
+	tt.jsonEqualsFile(tt.in().WithMessage(msgAndArgs...), 0, file, actualBytes, opts)
 }
 
 // TestJSONMarshaller is a convenient tool to test JSON marshalling/unmarshalling
 // matching the marshalled data to the referenced file.
-func (tt *TestTools) TestJSONMarshaller(filename string, sample interface{}) {
+func (tt *TestTools) TestJSONMarshaller(filename string, sample interface{}, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
+	in := tt.in().WithMessage(msgAndArgs...)
 	actual, err := json.Marshal(sample)
-	if Internal.NotOk(0, err) {
+	if in.NotOk(0, err) {
 		tt.Error(err)
 	}
 	sampleType := reflect.TypeOf(sample)
@@ -307,42 +512,46 @@ func (tt *TestTools) TestJSONMarshaller(filename string, sample interface{}) {
 		sampleType = sampleType.Elem()
 		sample = reflect.ValueOf(sample).Elem().Interface()
 	}
-	tt.jsonEqualsFile(0, filename, actual)
+	tt.jsonEqualsFile(in, 0, filename, actual, nil)
 	recoveredPtr := reflect.New(sampleType)
 	err = json.Unmarshal(actual, recoveredPtr.Interface())
-	if Internal.NotOk(0, err) {
+	if in.NotOk(0, err) {
 		tt.Error(err)
 	}
-	if Internal.NotEquals(0, sample, recoveredPtr.Elem().Interface()) {
+	if in.NotEquals(0, sample, recoveredPtr.Elem().Interface()) {
 		tt.Error(errors.New("Expressions don't match"))
 	}
 }
 
 // Fatal will fail the test immediately with an error message
 func (tt *TestTools) Fatal(args ...interface{}) {
-	Internal.Fatal(0, args...)
+	helperOf(tt.T)()
+	tt.in().Fatal(0, args...)
 	tt.Error(errors.New("Fatal error"))
 }
 
 // Fatalf will fail the test immediately with a formatted error message
 func (tt *TestTools) Fatalf(formatString string, args ...interface{}) {
-	Internal.Fatalf(0, formatString, args...)
+	helperOf(tt.T)()
+	tt.in().Fatalf(0, formatString, args...)
 	tt.Error(errors.New("Fatal error"))
 }
 
 // MustFail checks if err == nil. If so, it fails the test
 func (tt *TestTools) MustFail(err error, msg string, v ...interface{}) {
-	if Internal.NotAssert(0, err != nil, msg, v...) {
+	helperOf(tt.T)()
+	if tt.in().NotAssert(0, err != nil, msg, v...) {
 		tt.Error(fmt.Errorf("Should have failed: %s", msg))
 	}
 }
 
 // MustFailWith checks if err equals an expected error. If not, it will fail the test.
-func (tt *TestTools) MustFailWith(err error, expectedError error) {
+func (tt *TestTools) MustFailWith(err error, expectedError error, msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
 	msg := fmt.Sprintf("Expected error to be '%s'. Got '%s'",
 		Internal.ErrorString(expectedError),
 		Internal.ErrorString(err))
-	if Internal.NotAssert(0, err == expectedError, msg) {
+	if tt.in().WithMessage(msgAndArgs...).NotAssert(0, err == expectedError, msg) {
 		tt.Error(fmt.Errorf("Should have failed: %s", msg))
 	}
 }
@@ -363,22 +572,25 @@ func testPanic(f func()) (bool, interface{}) {
 }
 
 // MustPanic runs a function and checks that it panics
-func (tt *TestTools) MustPanic(f func()) {
+func (tt *TestTools) MustPanic(f func(), msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
 	didPanic, _ := testPanic(f)
 	msg := "Expected function to panic"
-	if Internal.NotAssert(0, didPanic, msg) {
+	if tt.in().WithMessage(msgAndArgs...).NotAssert(0, didPanic, msg) {
 		tt.Error(errors.New("should have panicked"))
 	}
 }
 
 // MustPanicWith runs a function and checks that it panics throwing a specific value
-func (tt *TestTools) MustPanicWith(expectedMessage interface{}, f func()) {
+func (tt *TestTools) MustPanicWith(expectedMessage interface{}, f func(), msgAndArgs ...interface{}) {
+	helperOf(tt.T)()
 	didPanic, recoveredMessage := testPanic(f)
 	msg := "Expected function to panic"
-	if Internal.NotAssert(0, didPanic, msg) {
+	in := tt.in().WithMessage(msgAndArgs...)
+	if in.NotAssert(0, didPanic, msg) {
 		tt.Error(errors.New("should have panicked"))
 	}
-	if Internal.NotEquals(0, expectedMessage, recoveredMessage) {
+	if in.NotEquals(0, expectedMessage, recoveredMessage) {
 		tt.Error(fmt.Errorf("Should have panicked with message: %v", expectedMessage))
 	}
 }
@@ -407,7 +619,7 @@ func (tt *TestTools) RoutineEnd() {
 	tt.W.Done()
 }
 
-//StartSubTest marks the beginning of a new subtest
+// StartSubTest marks the beginning of a new subtest
 func (tt *TestTools) StartSubTest(fmtString string, args ...interface{}) {
 	s := StringSubTest(fmt.Sprintf(fmtString, args...))
 	tt.SubTest = &s
@@ -422,46 +634,68 @@ func (tt *TestTools) EndSubTest() {
 func (tt *TestTools) FinishTest() {
 	tt.W.Wait()
 	close(tt.err)
-
-	for i := len(tt.services) - 1; i >= 0; i-- {
-		err := tt.services[i].Close()
-		if err != nil {
-			fmt.Printf("Error closing service: %s\n", err)
-		}
-	}
-	tt.services = nil
+	tt.closeServices()
 
 	e := recover()
 	if e != nil {
 		panic(e)
 	}
 
+	tt.saveReceivedKeys()
+
 	var errorCount int
 	for err := range tt.err {
 		if err != nil {
-			fmt.Printf("Error: %s\n", err)
+			tt.T.Errorf("Error: %s", err)
 			errorCount++
 		}
 	}
 	if errorCount > 0 {
 		if tt.SubTest != nil {
-			fmt.Printf("Failed subtest: %s\n", tt.SubTest.String())
+			tt.T.Logf("Failed subtest: %s", tt.SubTest.String())
 		}
-		fmt.Printf("%d errors\n", errorCount)
+		tt.T.Logf("%d errors", errorCount)
 		tt.T.FailNow()
 	}
-	if tt.generateResults {
-		if tt.Results != nil && len(tt.Results) > 0 {
-			resultsBytes, err := json.MarshalIndent(tt.Results, "", "\t")
-			if err != nil {
-				tt.T.Fatal("Cannot marshal results to JSON")
-			}
-			CreateDirectory(tt.TestdataDir)
-			err = ioutil.WriteFile(filepath.Join(tt.TestdataDir, "results.json"), resultsBytes, 0660)
-			if err != nil {
-				tt.T.Fatal("Cannot write results.json")
-			}
+	// results are only stored if the test passed, so a failing test doesn't
+	// overwrite them with what it produced before failing
+	if tt.resultsDirty && !tt.T.Failed() {
+		if tt.parent != nil {
+			tt.parent.resultsDirty = true
+		} else {
+			tt.saveResults()
 		}
+	}
+	if !tt.T.Failed() {
+		tt.checkOrphans()
+	}
+	if tt.generateResults && tt.parent == nil {
 		tt.T.Fatal("\n!!!!!\nTest actually passed :-), but GENERATE_RESULTS is activated. Set to false before committing!\n!!!!!\n")
 	}
 }
+
+// closeServices closes the services added to the test, last added first
+func (tt *TestTools) closeServices() {
+	for i := len(tt.services) - 1; i >= 0; i-- {
+		err := tt.services[i].Close()
+		if err != nil {
+			tt.T.Logf("Error closing service: %s", err)
+		}
+	}
+	tt.services = nil
+}
+
+// saveResults writes the key-value results to the corresponding
+// test folder /results.json file. Keys are written in sorted order
+// so that regenerating results only shows real changes
+func (tt *TestTools) saveResults() {
+	resultsBytes, err := json.MarshalIndent(tt.Results, "", "\t")
+	if err != nil {
+		tt.T.Fatal("Cannot marshal results to JSON")
+	}
+	CreateDirectory(tt.TestdataDir)
+	err = ioutil.WriteFile(filepath.Join(tt.TestdataDir, "results.json"), resultsBytes, 0660)
+	if err != nil {
+		tt.T.Fatal("Cannot write results.json")
+	}
+}
@@ -18,6 +18,8 @@ package ut_test
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -159,7 +161,7 @@ var batch = []metaTest{
 		failed: false,
 		early:  false,
 		handler: func(t *ut.TestTools) {
-			t.EqualsTextFile("test.txt", `some text2`)
+			t.EqualsTextFile("test.txt", `some text`)
 		},
 	},
 }
@@ -182,3 +184,14 @@ func TestTestTools(t *testing.T) {
 		}
 	}
 }
+
+func TestMissingResultFile(t *testing.T) {
+	ft, early, _ := MetaTester("MissingResultFile", func(tt *ut.TestTools) {
+		tt.TestdataDir = "testdata/TestMissingResultFile"
+		tt.EqualsFile("missing.json", 1)
+	})
+	expected := "Cannot open test result file " + filepath.Join("testdata/TestMissingResultFile", "missing.json") + ": open "
+	if output := ft.Output(); !early || !ft.fail || !strings.Contains(output, expected) || strings.Contains(output, "MISSING") {
+		t.Fatalf("Expected the missing file to be reported, got:\n%s", output)
+	}
+}
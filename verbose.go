@@ -0,0 +1,29 @@
+// Copyright 2018 The ut/microtest Authors
+// This file is part of ut/microtest library.
+//
+// This library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this library. If not, see <http://www.gnu.org/licenses/>.
+
+package ut
+
+import "os"
+
+// VerboseEnv is the environment variable that, when set, makes failures print
+// full documents along with the differences found
+const VerboseEnv = "UT_VERBOSE"
+
+// verbose tells whether failures should print all the details available,
+// such as full JSON documents
+func verbose() bool {
+	return os.Getenv(VerboseEnv) != ""
+}